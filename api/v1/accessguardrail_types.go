@@ -0,0 +1,83 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// AccessGuardrailSpec defines the desired state of AccessGuardrail
+type AccessGuardrailSpec struct {
+	// namespaceSelector selects the namespaces of the Ingresses the guardrail applies to, all namespaces when unset.
+	// +optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+
+	// minPrefixLengthIPv4 is the shortest IPv4 prefix allowed in an allowlist, f.ex 8 refuses 0.0.0.0/0.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=32
+	// +optional
+	MinPrefixLengthIPv4 *int32 `json:"minPrefixLengthIPv4,omitempty"`
+
+	// minPrefixLengthIPv6 is the shortest IPv6 prefix allowed in an allowlist.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=128
+	// +optional
+	MinPrefixLengthIPv6 *int32 `json:"minPrefixLengthIPv6,omitempty"`
+
+	// forbiddenCIDRs are ranges no allowlist entry may overlap.
+	// +listType=atomic
+	// +optional
+	ForbiddenCIDRs []string `json:"forbiddenCIDRs,omitempty"`
+
+	// allowedCIDRs are the ranges allowlist entries must lie within, any range is allowed when unset.
+	// +listType=atomic
+	// +optional
+	AllowedCIDRs []string `json:"allowedCIDRs,omitempty"`
+
+	// forbidDefaultsOptOut refuses Ingresses opting out of the default references of their namespace.
+	// +optional
+	ForbidDefaultsOptOut bool `json:"forbidDefaultsOptOut,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+
+// AccessGuardrail is the Schema for the accessguardrails API
+type AccessGuardrail struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// metadata is a standard object metadata
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty,omitzero"`
+
+	// spec defines the desired state of AccessGuardrail
+	// +required
+	Spec AccessGuardrailSpec `json:"spec"`
+}
+
+// +kubebuilder:object:root=true
+
+// AccessGuardrailList contains a list of AccessGuardrail
+type AccessGuardrailList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []AccessGuardrail `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&AccessGuardrail{}, &AccessGuardrailList{})
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// AccessScheduleSpec defines the desired state of AccessSchedule
+type AccessScheduleSpec struct {
+	// cron is the standard five field cron expression opening the window, f.ex "0 22 * * SAT".
+	// +kubebuilder:validation:MinLength=1
+	// +required
+	Cron string `json:"cron"`
+
+	// duration is how long the window stays open.
+	// +required
+	Duration metav1.Duration `json:"duration"`
+
+	// timeZone is the IANA time zone the cron expression is evaluated in, f.ex Europe/Oslo.
+	// +kubebuilder:default=UTC
+	// +optional
+	TimeZone string `json:"timeZone,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:printcolumn:name="Cron",type=string,JSONPath=`.spec.cron`
+// +kubebuilder:printcolumn:name="Duration",type=string,JSONPath=`.spec.duration`
+// +kubebuilder:printcolumn:name="Time Zone",type=string,JSONPath=`.spec.timeZone`
+
+// AccessSchedule is the Schema for the accessschedules API
+type AccessSchedule struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// metadata is a standard object metadata
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty,omitzero"`
+
+	// spec defines the desired state of AccessSchedule
+	// +required
+	Spec AccessScheduleSpec `json:"spec"`
+}
+
+// +kubebuilder:object:root=true
+
+// AccessScheduleList contains a list of AccessSchedule
+type AccessScheduleList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []AccessSchedule `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&AccessSchedule{}, &AccessScheduleList{})
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CIDRFeedFormat is the format of the document served by a CIDR feed.
+// +kubebuilder:validation:Enum=text;json;csv
+type CIDRFeedFormat string
+
+const (
+	// CIDRFeedFormatText is a plain text document with one or more prefixes per line.
+	CIDRFeedFormatText CIDRFeedFormat = "text"
+	// CIDRFeedFormatJSON is a JSON document, the prefixes are selected with a JSONPath expression.
+	CIDRFeedFormatJSON CIDRFeedFormat = "json"
+	// CIDRFeedFormatCSV is a CSV document, the prefixes are read from a column.
+	CIDRFeedFormatCSV CIDRFeedFormat = "csv"
+)
+
+// CIDRFeedSpec defines the desired state of CIDRFeed
+type CIDRFeedSpec struct {
+	// url is the HTTP(S) address the feed is fetched from.
+	// +kubebuilder:validation:Pattern=`^https?://`
+	// +required
+	URL string `json:"url"`
+
+	// format of the document served by the feed.
+	// +kubebuilder:default=text
+	// +optional
+	Format CIDRFeedFormat `json:"format,omitempty"`
+
+	// jsonPath selects the prefixes in a json document, f.ex {.prefixes[*].ip_prefix}.
+	// +optional
+	JSONPath string `json:"jsonPath,omitempty"`
+
+	// csvColumn is the zero based column holding the prefixes in a csv document.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	CSVColumn int `json:"csvColumn,omitempty"`
+
+	// refreshInterval is the time between fetches of the feed.
+	// +kubebuilder:default="1h"
+	// +optional
+	RefreshInterval metav1.Duration `json:"refreshInterval,omitempty"`
+
+	// maxAge is the age after which the last good data is considered stale and no longer used.
+	// The data never goes stale when unset.
+	// +optional
+	MaxAge *metav1.Duration `json:"maxAge,omitempty"`
+
+	// authSecretRef references a Secret with credentials for the feed,
+	// either a bearer token in the key "token", or the keys "username" and "password" for basic auth.
+	// +optional
+	AuthSecretRef *SecretAuthReference `json:"authSecretRef,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="URL",type=string,JSONPath=`.spec.url`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Stale",type=string,JSONPath=`.status.conditions[?(@.type=="Stale")].status`
+// +kubebuilder:printcolumn:name="Last Fetch",type=date,JSONPath=`.status.lastSuccessfulFetchTime`
+
+// CIDRFeed is the Schema for the cidrfeeds API
+type CIDRFeed struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// metadata is a standard object metadata
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty,omitzero"`
+
+	// spec defines the desired state of CIDRFeed
+	// +required
+	Spec CIDRFeedSpec `json:"spec"`
+
+	// status defines the observed state of CIDRFeed
+	// +optional
+	Status CIDRSourceStatus `json:"status,omitempty,omitzero"`
+}
+
+// +kubebuilder:object:root=true
+
+// CIDRFeedList contains a list of CIDRFeed
+type CIDRFeedList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CIDRFeed `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&CIDRFeed{}, &CIDRFeedList{})
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CIDRSetEntry is a single entry of a CIDR set.
+type CIDRSetEntry struct {
+	// cidr is the prefix of the entry, dns: and geo: entries are resolved like custom entries.
+	// +kubebuilder:validation:MinLength=1
+	// +required
+	CIDR string `json:"cidr"`
+
+	// expires is the time the entry is dropped, the entry never expires when unset.
+	// +optional
+	Expires *metav1.Time `json:"expires,omitempty"`
+
+	// schedule references an AccessSchedule as namespace/name, or name in the default namespace.
+	// The entry is only used while a window of the schedule is open.
+	// +optional
+	Schedule string `json:"schedule,omitempty"`
+
+	// description of the entry, f.ex who was granted access and why.
+	// +optional
+	Description string `json:"description,omitempty"`
+}
+
+// CIDRSetSpec defines the desired state of CIDRSet
+type CIDRSetSpec struct {
+	// entries of the set.
+	// +listType=atomic
+	// +optional
+	Entries []CIDRSetEntry `json:"entries,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// CIDRSet is the Schema for the cidrsets API
+type CIDRSet struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// metadata is a standard object metadata
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty,omitzero"`
+
+	// spec defines the desired state of CIDRSet
+	// +required
+	Spec CIDRSetSpec `json:"spec"`
+}
+
+// +kubebuilder:object:root=true
+
+// CIDRSetList contains a list of CIDRSet
+type CIDRSetList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CIDRSet `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&CIDRSet{}, &CIDRSetList{})
+}
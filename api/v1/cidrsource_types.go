@@ -0,0 +1,60 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// CIDRSourceConditionReady reports whether the last fetch of the source succeeded.
+	CIDRSourceConditionReady = "Ready"
+	// CIDRSourceConditionStale reports whether the last good data is older than the max age.
+	CIDRSourceConditionStale = "Stale"
+)
+
+// SecretAuthReference points to a Secret in the same namespace holding credentials.
+type SecretAuthReference struct {
+	// name of the Secret.
+	// +required
+	Name string `json:"name"`
+}
+
+// CIDRSourceStatus defines the observed state of a source fetching prefixes from a remote system.
+type CIDRSourceStatus struct {
+	// prefixes is the last good list of prefixes fetched from the source.
+	// +optional
+	Prefixes []string `json:"prefixes,omitempty"`
+
+	// lastFetchTime is the time of the last fetch attempt.
+	// +optional
+	LastFetchTime *metav1.Time `json:"lastFetchTime,omitempty"`
+
+	// lastSuccessfulFetchTime is the time the prefixes were fetched.
+	// +optional
+	LastSuccessfulFetchTime *metav1.Time `json:"lastSuccessfulFetchTime,omitempty"`
+
+	// observedGeneration is the generation of the spec the prefixes were fetched for.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// conditions represent the current state of the source.
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// EmergencyBlockSpec defines the desired state of EmergencyBlock
+type EmergencyBlockSpec struct {
+	// cidr is the range denied to every managed Ingress, f.ex 203.0.113.7/32.
+	// +required
+	CIDR string `json:"cidr"`
+
+	// reason is why the range is blocked, shown in events and audit records.
+	// +required
+	Reason string `json:"reason"`
+
+	// expires is when the block is lifted and the EmergencyBlock is deleted.
+	// +required
+	Expires metav1.Time `json:"expires"`
+
+	// requestedBy is the user who added the block through the incident API.
+	// +optional
+	RequestedBy string `json:"requestedBy,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="CIDR",type=string,JSONPath=`.spec.cidr`
+// +kubebuilder:printcolumn:name="Expires",type=date,JSONPath=`.spec.expires`
+// +kubebuilder:printcolumn:name="Reason",type=string,JSONPath=`.spec.reason`
+
+// EmergencyBlock is the Schema for the emergencyblocks API
+type EmergencyBlock struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// metadata is a standard object metadata
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty,omitzero"`
+
+	// spec defines the desired state of EmergencyBlock
+	// +required
+	Spec EmergencyBlockSpec `json:"spec"`
+}
+
+// +kubebuilder:object:root=true
+
+// EmergencyBlockList contains a list of EmergencyBlock
+type EmergencyBlockList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []EmergencyBlock `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&EmergencyBlock{}, &EmergencyBlockList{})
+}
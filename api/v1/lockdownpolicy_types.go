@@ -0,0 +1,80 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// LockdownPolicySpec defines the desired state of LockdownPolicy
+type LockdownPolicySpec struct {
+	// references are the break-glass policies and sources replacing the allowlist of locked down Ingresses,
+	// in the syntax of the whitelist-policy annotation, f.ex ops-vpn. Sources are resolved in the operator namespace.
+	// +listType=atomic
+	// +optional
+	References []string `json:"references,omitempty"`
+
+	// entries are break-glass CIDRs replacing the allowlist of locked down Ingresses,
+	// in the syntax of the whitelist annotation, f.ex 192.0.2.0/24 or dns:vpn.example.com.
+	// +listType=atomic
+	// +optional
+	Entries []string `json:"entries,omitempty"`
+
+	// active locks down every managed Ingress in the namespaces selected by namespaceSelector.
+	// Ingresses and namespaces are locked down individually with the lockdown annotation naming the policy.
+	// +optional
+	Active bool `json:"active,omitempty"`
+
+	// namespaceSelector limits an active policy to the selected namespaces, all namespaces when unset.
+	// +optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+
+	// reason is why the policy is active, shown in the events of locked down Ingresses.
+	// +optional
+	Reason string `json:"reason,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Active",type=boolean,JSONPath=`.spec.active`
+// +kubebuilder:printcolumn:name="Reason",type=string,JSONPath=`.spec.reason`
+
+// LockdownPolicy is the Schema for the lockdownpolicies API
+type LockdownPolicy struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// metadata is a standard object metadata
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty,omitzero"`
+
+	// spec defines the desired state of LockdownPolicy
+	// +required
+	Spec LockdownPolicySpec `json:"spec"`
+}
+
+// +kubebuilder:object:root=true
+
+// LockdownPolicyList contains a list of LockdownPolicy
+type LockdownPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []LockdownPolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&LockdownPolicy{}, &LockdownPolicyList{})
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// MandatoryDenylistSpec defines the desired state of MandatoryDenylist
+type MandatoryDenylistSpec struct {
+	// references are policies and sources denied to every managed Ingress, in the syntax of the
+	// denylist-policy annotation, f.ex abuse-ranges or feed:sanctioned-networks.
+	// Sources are resolved in the operator namespace.
+	// +listType=atomic
+	// +optional
+	References []string `json:"references,omitempty"`
+
+	// entries are CIDRs denied to every managed Ingress, in the syntax of the denylist annotation,
+	// f.ex 198.51.100.0/24, dns:abuse.example.com or 203.0.113.0/24@2026-12-31.
+	// +listType=atomic
+	// +optional
+	Entries []string `json:"entries,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+
+// MandatoryDenylist is the Schema for the mandatorydenylists API
+type MandatoryDenylist struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// metadata is a standard object metadata
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty,omitzero"`
+
+	// spec defines the desired state of MandatoryDenylist
+	// +required
+	Spec MandatoryDenylistSpec `json:"spec"`
+}
+
+// +kubebuilder:object:root=true
+
+// MandatoryDenylistList contains a list of MandatoryDenylist
+type MandatoryDenylistList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []MandatoryDenylist `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&MandatoryDenylist{}, &MandatoryDenylistList{})
+}
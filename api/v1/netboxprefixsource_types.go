@@ -0,0 +1,101 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// NetBoxPrefixSourceSpec defines the desired state of NetBoxPrefixSource
+type NetBoxPrefixSourceSpec struct {
+	// url is the address of the NetBox instance, f.ex https://netbox.example.com.
+	// +kubebuilder:validation:Pattern=`^https?://`
+	// +required
+	URL string `json:"url"`
+
+	// tokenSecretRef references a Secret holding the NetBox API token in the key "token".
+	// +required
+	TokenSecretRef SecretAuthReference `json:"tokenSecretRef"`
+
+	// tags selects prefixes having all the tags, by slug.
+	// +optional
+	Tags []string `json:"tags,omitempty"`
+
+	// roles selects prefixes having one of the roles, by slug.
+	// +optional
+	Roles []string `json:"roles,omitempty"`
+
+	// sites selects prefixes in one of the sites, by slug.
+	// +optional
+	Sites []string `json:"sites,omitempty"`
+
+	// vrfs selects prefixes in one of the VRFs, by route distinguisher.
+	// +optional
+	VRFs []string `json:"vrfs,omitempty"`
+
+	// status selects prefixes with the status.
+	// +kubebuilder:default=active
+	// +optional
+	Status string `json:"status,omitempty"`
+
+	// refreshInterval is the time between queries to NetBox.
+	// +kubebuilder:default="1h"
+	// +optional
+	RefreshInterval metav1.Duration `json:"refreshInterval,omitempty"`
+
+	// maxAge is the age after which the last good data is considered stale and no longer used.
+	// The data never goes stale when unset.
+	// +optional
+	MaxAge *metav1.Duration `json:"maxAge,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="URL",type=string,JSONPath=`.spec.url`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Stale",type=string,JSONPath=`.status.conditions[?(@.type=="Stale")].status`
+// +kubebuilder:printcolumn:name="Last Fetch",type=date,JSONPath=`.status.lastSuccessfulFetchTime`
+
+// NetBoxPrefixSource is the Schema for the netboxprefixsources API
+type NetBoxPrefixSource struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// metadata is a standard object metadata
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty,omitzero"`
+
+	// spec defines the desired state of NetBoxPrefixSource
+	// +required
+	Spec NetBoxPrefixSourceSpec `json:"spec"`
+
+	// status defines the observed state of NetBoxPrefixSource
+	// +optional
+	Status CIDRSourceStatus `json:"status,omitempty,omitzero"`
+}
+
+// +kubebuilder:object:root=true
+
+// NetBoxPrefixSourceList contains a list of NetBoxPrefixSource
+type NetBoxPrefixSourceList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []NetBoxPrefixSource `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&NetBoxPrefixSource{}, &NetBoxPrefixSourceList{})
+}
@@ -0,0 +1,179 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	ingressnetworkpoliciesv1 "github.com/vitistack/ingressnetworkpolicy-operator/api/v1"
+	"github.com/vitistack/ingressnetworkpolicy-operator/internal/controller"
+)
+
+const blockUsage = `Usage: manager block add CIDR --reason REASON [--ttl 1h]
+       manager block remove CIDR
+       manager block list
+
+Adds, removes or lists the emergency blocks of the incident API.
+The server and token default to $INCIDENT_API_SERVER and $INCIDENT_API_TOKEN.
+
+Flags:
+`
+
+// runBlockCommand runs the block subcommand, a client of the incident API served by the manager.
+func runBlockCommand(args []string) error {
+	flags := flag.NewFlagSet("block", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), blockUsage)
+		flags.PrintDefaults()
+	}
+	server := flags.String("server", os.Getenv("INCIDENT_API_SERVER"), "The URL of the incident API, f.ex https://localhost:9444.")
+	token := flags.String("token", os.Getenv("INCIDENT_API_TOKEN"), "The bearer token, f.ex from kubectl create token.")
+	tokenFile := flags.String("token-file", "", "A file holding the bearer token.")
+	caFile := flags.String("ca-file", "", "A file holding the CA certificate of the incident API.")
+	insecure := flags.Bool("insecure-skip-tls-verify", false, "If set, the certificate of the incident API is not verified.")
+	ttl := flags.String("ttl", "", "How long the block lasts, f.ex 30m. The server default is used when empty.")
+	reason := flags.String("reason", "", "Why the range is blocked.")
+	timeout := flags.Duration("timeout", 10*time.Second, "The timeout of the request.")
+
+	if len(args) == 0 {
+		flags.Usage()
+		return errors.New("missing block command")
+	}
+	command := args[0]
+
+	// Accept flags both before and after the CIDR
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+	var cidr string
+	if flags.NArg() > 0 {
+		cidr = flags.Arg(0)
+		if err := flags.Parse(flags.Args()[1:]); err != nil {
+			return err
+		}
+	}
+	if flags.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments %v", flags.Args())
+	}
+
+	if *server == "" {
+		return errors.New("missing --server or $INCIDENT_API_SERVER")
+	}
+	if *tokenFile != "" {
+		content, err := os.ReadFile(*tokenFile)
+		if err != nil {
+			return err
+		}
+		*token = strings.TrimSpace(string(content))
+	}
+	if *token == "" {
+		return errors.New("missing --token, --token-file or $INCIDENT_API_TOKEN")
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: *insecure} //nolint:gosec // opt-in for self-signed certificates
+	if *caFile != "" {
+		ca, err := os.ReadFile(*caFile)
+		if err != nil {
+			return err
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(ca) {
+			return fmt.Errorf("no certificates found in %s", *caFile)
+		}
+	}
+	httpClient := &http.Client{Timeout: *timeout, Transport: &http.Transport{TLSClientConfig: tlsConfig}}
+	endpoint := strings.TrimSuffix(*server, "/") + "/v1/blocks"
+
+	var req *http.Request
+	var err error
+	switch command {
+	case "add":
+		if cidr == "" {
+			return errors.New("missing CIDR")
+		}
+		body, _ := json.Marshal(controller.EmergencyBlockRequest{CIDR: cidr, TTL: *ttl, Reason: *reason})
+		req, err = http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(body))
+		if req != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+	case "remove":
+		if cidr == "" {
+			return errors.New("missing CIDR")
+		}
+		req, err = http.NewRequest(http.MethodDelete, endpoint+"?cidr="+url.QueryEscape(cidr), nil)
+	case "list":
+		req, err = http.NewRequest(http.MethodGet, endpoint, nil)
+	default:
+		flags.Usage()
+		return fmt.Errorf("unknown block command %q", command)
+	}
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+*token)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	switch command {
+	case "add":
+		var block ingressnetworkpoliciesv1.EmergencyBlock
+		if err := json.Unmarshal(body, &block); err != nil {
+			return err
+		}
+		fmt.Printf("blocked %s until %s\n", block.Spec.CIDR, block.Spec.Expires.UTC().Format(time.RFC3339))
+	case "remove":
+		fmt.Printf("removed the block of %s\n", cidr)
+	case "list":
+		var blocks []ingressnetworkpoliciesv1.EmergencyBlock
+		if err := json.Unmarshal(body, &blocks); err != nil {
+			return err
+		}
+		writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(writer, "CIDR\tEXPIRES\tREQUESTED BY\tREASON")
+		for _, block := range blocks {
+			fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", block.Spec.CIDR, block.Spec.Expires.UTC().Format(time.RFC3339), block.Spec.RequestedBy, block.Spec.Reason)
+		}
+		return writer.Flush()
+	}
+
+	return nil
+}
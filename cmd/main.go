@@ -19,16 +19,26 @@ package main
 import (
 	"crypto/tls"
 	"flag"
+	"fmt"
+	"net/http"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	// Embed the time zone database for AccessSchedules, the distroless image has none
+	_ "time/tzdata"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/certwatcher"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/filters"
@@ -37,6 +47,7 @@ import (
 
 	ingressnetworkpoliciesv1 "github.com/vitistack/ingressnetworkpolicy-operator/api/v1"
 	"github.com/vitistack/ingressnetworkpolicy-operator/internal/controller"
+	webhookv1 "github.com/vitistack/ingressnetworkpolicy-operator/internal/webhook/v1"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -54,6 +65,15 @@ func init() {
 
 // nolint:gocyclo
 func main() {
+	// The block subcommand is a client of the incident API
+	if len(os.Args) > 1 && os.Args[1] == "block" {
+		if err := runBlockCommand(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	var metricsAddr string
 	var metricsCertPath, metricsCertName, metricsCertKey string
 	var webhookCertPath, webhookCertName, webhookCertKey string
@@ -62,6 +82,19 @@ func main() {
 	var secureMetrics bool
 	var enableHTTP2 bool
 	var tlsOpts []func(*tls.Config)
+	var feedTimeout time.Duration
+	var dnsMinTTL time.Duration
+	var dnsServers string
+	var geoIPDatabasePath string
+	var geoIPMaxPrefixes int
+	var geoIPReloadInterval time.Duration
+	var enableWebhooks bool
+	var incidentAPIAddr string
+	var incidentAPICertPath, incidentAPICertName, incidentAPICertKey string
+	var incidentAPIInsecure bool
+	var incidentDefaultTTL, incidentMaxTTL time.Duration
+	var incidentMinPrefixIPv4, incidentMinPrefixIPv6 int
+	var acmeSolverPolicy, acmeCARanges string
 	flag.StringVar(&metricsAddr, "metrics-bind-address", "0", "The address the metrics endpoint binds to. "+
 		"Use :8443 for HTTPS or :8080 for HTTP, or leave as 0 to disable the metrics service.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
@@ -77,6 +110,37 @@ func main() {
 		"The directory that contains the metrics server certificate.")
 	flag.StringVar(&metricsCertName, "metrics-cert-name", "tls.crt", "The name of the metrics server certificate file.")
 	flag.StringVar(&metricsCertKey, "metrics-cert-key", "tls.key", "The name of the metrics server key file.")
+	flag.DurationVar(&feedTimeout, "feed-timeout", 30*time.Second, "The timeout for fetching a CIDRFeed or querying a NetBoxPrefixSource.")
+	flag.DurationVar(&dnsMinTTL, "dns-min-ttl", time.Minute,
+		"The minimum time between resolutions of hosts in dns: entries.")
+	flag.StringVar(&dnsServers, "dns-servers", "",
+		"Comma separated host:port addresses of the DNS servers resolving dns: entries. Defaults to the servers of /etc/resolv.conf.")
+	flag.StringVar(&geoIPDatabasePath, "geoip-database", "",
+		"The path of a MaxMind or DB-IP country MMDB file resolving geo: entries. geo: entries are skipped when unset.")
+	flag.IntVar(&geoIPMaxPrefixes, "geoip-max-prefixes", 5000,
+		"The maximum number of aggregated prefixes of a country in geo: entries, larger countries are refused in allowlists and covered by shorter prefixes in denylists.")
+	flag.DurationVar(&geoIPReloadInterval, "geoip-reload-interval", time.Minute,
+		"The time between checks of the GeoIP database file for changes.")
+	flag.BoolVar(&enableWebhooks, "enable-webhooks", false,
+		"If set, Ingresses are validated against the AccessGuardrails at admission. Requires the webhook certificates.")
+	flag.StringVar(&incidentAPIAddr, "incident-api-bind-address", "0",
+		"The address the incident API adding emergency blocks binds to. Use 0 to disable it.")
+	flag.StringVar(&incidentAPICertPath, "incident-api-cert-path", "",
+		"The directory that contains the incident API certificate. The API doesn't start without one, unless --incident-api-insecure is set.")
+	flag.BoolVar(&incidentAPIInsecure, "incident-api-insecure", false,
+		"If set, the incident API is served over plain HTTP without --incident-api-cert-path, sending bearer tokens in clear text.")
+	flag.StringVar(&incidentAPICertName, "incident-api-cert-name", "tls.crt", "The name of the incident API certificate file.")
+	flag.StringVar(&incidentAPICertKey, "incident-api-cert-key", "tls.key", "The name of the incident API key file.")
+	flag.DurationVar(&incidentDefaultTTL, "incident-default-ttl", time.Hour, "The TTL of emergency blocks added without one.")
+	flag.DurationVar(&incidentMaxTTL, "incident-max-ttl", 24*time.Hour, "The longest TTL of emergency blocks added through the incident API.")
+	flag.IntVar(&incidentMinPrefixIPv4, "incident-min-prefix-ipv4", 16,
+		"The shortest IPv4 prefix length of emergency blocks added through the incident API. Use 0 to accept any range.")
+	flag.IntVar(&incidentMinPrefixIPv6, "incident-min-prefix-ipv6", 32,
+		"The shortest IPv6 prefix length of emergency blocks added through the incident API. Use 0 to accept any range.")
+	flag.StringVar(&acmeSolverPolicy, "acme-solver-policy", controller.ACMEPolicyExempt,
+		"How cert-manager HTTP-01 solver Ingresses are handled: exempt leaves them without access lists, ca-ranges only allows --acme-ca-ranges.")
+	flag.StringVar(&acmeCARanges, "acme-ca-ranges", "",
+		"Comma separated validation ranges of the certificate authority, allowed on solver Ingresses and on restricted Ingresses with challenge paths.")
 	flag.BoolVar(&enableHTTP2, "enable-http2", false,
 		"If set, HTTP/2 will be enabled for the metrics and webhook servers")
 	opts := zap.Options{
@@ -155,7 +219,14 @@ func main() {
 	}
 
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
-		Scheme:                 scheme,
+		Scheme: scheme,
+		// ConfigMaps and Secrets referenced as CIDR sources are read directly from the API server
+		// instead of caching every ConfigMap and Secret in the cluster.
+		Client: client.Options{
+			Cache: &client.CacheOptions{
+				DisableFor: []client.Object{&corev1.ConfigMap{}, &corev1.Secret{}},
+			},
+		},
 		Metrics:                metricsServerOptions,
 		WebhookServer:          webhookServer,
 		HealthProbeBindAddress: probeAddr,
@@ -178,9 +249,34 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Operator-wide configuration shared by the reconcilers computing access lists
+	accessConfig := controller.AccessConfig{
+		DNS:      controller.NewDNSCache(&controller.DNSServerResolver{Servers: splitList(dnsServers)}, dnsMinTTL),
+		Recorder: mgr.GetEventRecorderFor("ingressnetworkpolicy-operator"),
+		ACME:     controller.ACMEPolicy{Mode: acmeSolverPolicy, CARanges: splitList(acmeCARanges)},
+	}
+	if err := accessConfig.ACME.Validate(); err != nil {
+		setupLog.Error(err, "invalid ACME policy")
+		os.Exit(1)
+	}
+
+	if geoIPDatabasePath != "" {
+		geoIPDatabase, err := controller.NewGeoIPDatabase(geoIPDatabasePath, geoIPMaxPrefixes, geoIPReloadInterval)
+		if err != nil {
+			setupLog.Error(err, "unable to load GeoIP database")
+			os.Exit(1)
+		}
+		if err := mgr.Add(geoIPDatabase); err != nil {
+			setupLog.Error(err, "unable to add GeoIP database reloader")
+			os.Exit(1)
+		}
+		accessConfig.GeoIP = geoIPDatabase
+	}
+
 	if err := (&controller.IngressReconciler{
 		Client: mgr.GetClient(),
 		Scheme: mgr.GetScheme(),
+		Access: accessConfig,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Ingress")
 		os.Exit(1)
@@ -188,10 +284,85 @@ func main() {
 	if err := (&controller.NetworkPolicyReconciler{
 		Client: mgr.GetClient(),
 		Scheme: mgr.GetScheme(),
+		Access: accessConfig,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "NetworkPolicy")
 		os.Exit(1)
 	}
+	if err := (&controller.CIDRFeedReconciler{
+		Client:     mgr.GetClient(),
+		Scheme:     mgr.GetScheme(),
+		HTTPClient: &http.Client{Timeout: feedTimeout},
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "CIDRFeed")
+		os.Exit(1)
+	}
+	if err := (&controller.NetBoxPrefixSourceReconciler{
+		Client:     mgr.GetClient(),
+		Scheme:     mgr.GetScheme(),
+		HTTPClient: &http.Client{Timeout: feedTimeout},
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "NetBoxPrefixSource")
+		os.Exit(1)
+	}
+	if err := (&controller.EmergencyBlockReconciler{
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Recorder: accessConfig.Recorder,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "EmergencyBlock")
+		os.Exit(1)
+	}
+	if incidentAPIAddr != "" && incidentAPIAddr != "0" {
+		incidentAPI := &controller.IncidentAPI{
+			Client:      mgr.GetClient(),
+			Authorizer:  controller.TokenReviewAuthorizer{Client: mgr.GetClient()},
+			Recorder:    accessConfig.Recorder,
+			BindAddress: incidentAPIAddr,
+			Insecure:    incidentAPIInsecure,
+			DefaultTTL:  incidentDefaultTTL,
+			MaxTTL:      incidentMaxTTL,
+
+			MinPrefixLengthIPv4: incidentMinPrefixIPv4,
+			MinPrefixLengthIPv6: incidentMinPrefixIPv6,
+		}
+		if len(incidentAPICertPath) == 0 && !incidentAPIInsecure {
+			setupLog.Error(fmt.Errorf("no certificate configured"),
+				"the incident API requires --incident-api-cert-path, or --incident-api-insecure to serve plain HTTP")
+			os.Exit(1)
+		}
+		if len(incidentAPICertPath) > 0 {
+			setupLog.Info("Initializing incident API certificate watcher using provided certificates",
+				"incident-api-cert-path", incidentAPICertPath, "incident-api-cert-name", incidentAPICertName, "incident-api-cert-key", incidentAPICertKey)
+
+			incidentCertWatcher, err := certwatcher.New(
+				filepath.Join(incidentAPICertPath, incidentAPICertName),
+				filepath.Join(incidentAPICertPath, incidentAPICertKey),
+			)
+			if err != nil {
+				setupLog.Error(err, "unable to initialize incident API certificate watcher")
+				os.Exit(1)
+			}
+			if err := mgr.Add(incidentCertWatcher); err != nil {
+				setupLog.Error(err, "unable to add incident API certificate watcher")
+				os.Exit(1)
+			}
+			incidentAPI.TLSConfig = &tls.Config{GetCertificate: incidentCertWatcher.GetCertificate}
+			for _, tlsOpt := range tlsOpts {
+				tlsOpt(incidentAPI.TLSConfig)
+			}
+		}
+		if err := mgr.Add(incidentAPI); err != nil {
+			setupLog.Error(err, "unable to add incident API")
+			os.Exit(1)
+		}
+	}
+	if enableWebhooks {
+		if err := webhookv1.SetupIngressWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "Ingress")
+			os.Exit(1)
+		}
+	}
 	// +kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
@@ -209,3 +380,14 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// splitList splits a comma separated flag value, dropping empty items.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
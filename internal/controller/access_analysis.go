@@ -0,0 +1,220 @@
+package controller
+
+import (
+	"cmp"
+	"maps"
+	"net/netip"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/api/networking/v1"
+)
+
+// Findings of the access analysis, used as event reasons and metric labels.
+const (
+	FindingShadowedAllow   = "ShadowedAllowEntries"
+	FindingRedundantAllow  = "RedundantAllowEntries"
+	FindingUnreachableDeny = "UnreachableDenyEntries"
+	FindingDenyHole        = "DenylistHoles"
+)
+
+// accessFindingMessages describe the findings in events.
+var accessFindingMessages = map[string]string{
+	FindingShadowedAllow:   "Allow entries fully covered by the denylist: %s",
+	FindingRedundantAllow:  "Allow entries covered by other allow entries: %s",
+	FindingUnreachableDeny: "Deny entries outside every allowed range: %s",
+	FindingDenyHole:        "Deny entries carving holes in allowed ranges: %s",
+}
+
+// accessFindings describes how the entries of the allow- and denylist of an Ingress overlap.
+type accessFindings struct {
+	// shadowed are allow entries fully covered by the denylist.
+	shadowed []string
+	// redundant are allow entries covered by another allow entry.
+	redundant []string
+	// unreachable are deny entries outside every allowed range.
+	unreachable []string
+	// holes are deny entries carving a hole in an allowed range.
+	holes []string
+}
+
+// byFinding returns the entries of each finding, keyed by finding.
+func (f accessFindings) byFinding() map[string][]string {
+	return map[string][]string{
+		FindingShadowedAllow:   f.shadowed,
+		FindingRedundantAllow:  f.redundant,
+		FindingUnreachableDeny: f.unreachable,
+		FindingDenyHole:        f.holes,
+	}
+}
+
+// analyzeAccess computes how the entries of the allow- and denylist overlap.
+// Deny entries are only unreachable or holes when there is an allowlist, without one every address is allowed.
+func analyzeAccess(allow []string, deny []string) accessFindings {
+	allowPrefixes := parsePrefixes(allow)
+	denyPrefixes := parsePrefixes(deny)
+
+	var findings accessFindings
+
+	for _, prefix := range allowPrefixes {
+		if len(subtractPrefixes([]netip.Prefix{prefix}, denyPrefixes)) == 0 {
+			findings.shadowed = append(findings.shadowed, prefix.String())
+		}
+	}
+
+	// Sorted by address, a prefix covering others comes right before them
+	var cover netip.Prefix
+	for _, prefix := range sortPrefixes(allowPrefixes) {
+		if cover.IsValid() && cover.Bits() <= prefix.Bits() && cover.Contains(prefix.Addr()) {
+			findings.redundant = append(findings.redundant, prefix.String())
+			continue
+		}
+		cover = prefix
+	}
+
+	if len(allowPrefixes) == 0 {
+		return findings
+	}
+
+	for _, prefix := range denyPrefixes {
+		overlapping := slices.ContainsFunc(allowPrefixes, prefix.Overlaps)
+		if overlapping {
+			findings.holes = append(findings.holes, prefix.String())
+		} else {
+			findings.unreachable = append(findings.unreachable, prefix.String())
+		}
+	}
+
+	return findings
+}
+
+// subtractDenylist reports whether the denylist should be subtracted from the allowlist
+// instead of being rendered, for ingress controllers without deny support.
+func subtractDenylist(ingressClass *v1.IngressClass) bool {
+	return ingressClass != nil && ingressClass.GetAnnotations()[AnnotationSubtractDenylist] == "true"
+}
+
+// subtractCIDRs returns the allowed ranges not covered by the denied ranges, as a sorted list of prefixes.
+func subtractCIDRs(allow []string, deny []string) []string {
+	var cidrs []string
+	for _, prefix := range sortPrefixes(subtractPrefixes(parsePrefixes(allow), parsePrefixes(deny))) {
+		cidrs = append(cidrs, prefix.String())
+	}
+	return cidrs
+}
+
+// subtractPrefixes returns the parts of the prefixes not covered by the removed prefixes.
+// Prefixes partly covered are split in halves until the halves are either covered or clear.
+func subtractPrefixes(prefixes []netip.Prefix, remove []netip.Prefix) []netip.Prefix {
+	var result []netip.Prefix
+
+	var subtract func(prefix netip.Prefix, remove []netip.Prefix)
+	subtract = func(prefix netip.Prefix, remove []netip.Prefix) {
+		var overlapping []netip.Prefix
+		for _, removed := range remove {
+			if !removed.Overlaps(prefix) {
+				continue
+			}
+			if removed.Bits() <= prefix.Bits() {
+				return
+			}
+			overlapping = append(overlapping, removed)
+		}
+
+		if len(overlapping) == 0 {
+			result = append(result, prefix)
+			return
+		}
+
+		lower, upper := splitPrefix(prefix)
+		subtract(lower, overlapping)
+		subtract(upper, overlapping)
+	}
+
+	for _, prefix := range prefixes {
+		subtract(prefix, remove)
+	}
+	return result
+}
+
+// splitPrefix splits the prefix into its lower and upper half.
+func splitPrefix(prefix netip.Prefix) (netip.Prefix, netip.Prefix) {
+	bits := prefix.Bits() + 1
+	lower := netip.PrefixFrom(prefix.Addr(), bits)
+
+	address := prefix.Addr().AsSlice()
+	address[prefix.Bits()/8] |= 0x80 >> (prefix.Bits() % 8)
+	upperAddress, _ := netip.AddrFromSlice(address)
+
+	return lower, netip.PrefixFrom(upperAddress, bits)
+}
+
+// parsePrefixes parses the CIDRs to masked prefixes, skipping invalid ones.
+func parsePrefixes(cidrs []string) []netip.Prefix {
+	var prefixes []netip.Prefix
+	for _, cidr := range cidrs {
+		if prefix, err := netip.ParsePrefix(strings.TrimSpace(cidr)); err == nil {
+			prefixes = append(prefixes, prefix.Masked())
+		}
+	}
+	return prefixes
+}
+
+// sortPrefixes sorts the prefixes by address, and shorter prefixes first.
+func sortPrefixes(prefixes []netip.Prefix) []netip.Prefix {
+	slices.SortFunc(prefixes, func(a netip.Prefix, b netip.Prefix) int {
+		return cmp.Or(a.Addr().Compare(b.Addr()), cmp.Compare(a.Bits(), b.Bits()))
+	})
+	return prefixes
+}
+
+// formatAccessFindings formats the findings for the findings annotation, f.ex
+// RedundantAllowEntries=10.0.1.0/24;UnreachableDenyEntries=192.0.2.0/24.
+func formatAccessFindings(findings map[string][]string) string {
+	var parts []string
+	for _, finding := range slices.Sorted(maps.Keys(findings)) {
+		if len(findings[finding]) > 0 {
+			parts = append(parts, finding+"="+strings.Join(findings[finding], ","))
+		}
+	}
+	return strings.Join(parts, ";")
+}
+
+// parseAccessFindings parses the findings annotation.
+func parseAccessFindings(annotation string) map[string][]string {
+	findings := map[string][]string{}
+	for _, part := range filterSliceFromString(strings.Split(annotation, ";")) {
+		if finding, entries, found := strings.Cut(part, "="); found {
+			findings[finding] = filterSliceFromString(strings.Split(entries, ","))
+		}
+	}
+	return findings
+}
+
+// recordAccessFindings records the findings of the Ingress in an annotation,
+// and emits an event and counts the findings that changed since the last update.
+func recordAccessFindings(config AccessConfig, ingress *v1.Ingress, findings accessFindings) {
+	previous := parseAccessFindings(ingress.Annotations[AnnotationAccessFindings])
+	current := findings.byFinding()
+
+	for _, finding := range slices.Sorted(maps.Keys(current)) {
+		entries := current[finding]
+		if len(entries) == 0 || slices.Equal(entries, previous[finding]) {
+			continue
+		}
+
+		accessFindingsTotal.WithLabelValues(finding).Inc()
+
+		if config.Recorder == nil {
+			continue
+		}
+		eventType := corev1.EventTypeWarning
+		if finding == FindingDenyHole {
+			eventType = corev1.EventTypeNormal
+		}
+		config.Recorder.Eventf(ingress, eventType, finding, accessFindingMessages[finding], strings.Join(entries, ","))
+	}
+
+	setOrDeleteAnnotation(ingress, AnnotationAccessFindings, formatAccessFindings(current))
+}
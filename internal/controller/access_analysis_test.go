@@ -0,0 +1,150 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var _ = Describe("Access analysis", func() {
+	ctx := context.Background()
+
+	It("should flag shadowed, redundant and unreachable entries and holes", func() {
+		findings := analyzeAccess(
+			[]string{"10.0.0.0/16", "10.0.1.0/24", "172.16.0.0/24", "2001:db8::/32"},
+			[]string{"10.0.2.0/24", "172.16.0.0/25", "172.16.0.128/25", "192.0.2.0/24"},
+		)
+		Expect(findings.shadowed).To(Equal([]string{"172.16.0.0/24"}))
+		Expect(findings.redundant).To(Equal([]string{"10.0.1.0/24"}))
+		Expect(findings.holes).To(Equal([]string{"10.0.2.0/24", "172.16.0.0/25", "172.16.0.128/25"}))
+		Expect(findings.unreachable).To(Equal([]string{"192.0.2.0/24"}))
+	})
+
+	It("should not flag deny entries without an allowlist", func() {
+		findings := analyzeAccess(nil, []string{"192.0.2.0/24"})
+		Expect(findings.unreachable).To(BeEmpty())
+		Expect(findings.holes).To(BeEmpty())
+	})
+
+	It("should subtract the denylist from the allowlist", func() {
+		Expect(subtractCIDRs([]string{"10.0.0.0/22", "2001:db8::/32"}, []string{"10.0.1.0/24", "2001:db8::/33"})).To(Equal([]string{
+			"10.0.0.0/24", "10.0.2.0/23", "2001:db8:8000::/33",
+		}))
+		Expect(subtractCIDRs([]string{"10.0.0.0/24"}, []string{"10.0.0.0/8"})).To(BeEmpty())
+	})
+
+	It("should round trip the findings annotation", func() {
+		findings := accessFindings{redundant: []string{"10.0.1.0/24"}, unreachable: []string{"192.0.2.0/24", "198.51.100.0/24"}}
+		annotation := formatAccessFindings(findings.byFinding())
+		Expect(annotation).To(Equal("RedundantAllowEntries=10.0.1.0/24;UnreachableDenyEntries=192.0.2.0/24,198.51.100.0/24"))
+		Expect(parseAccessFindings(annotation)).To(Equal(map[string][]string{
+			FindingRedundantAllow:  {"10.0.1.0/24"},
+			FindingUnreachableDeny: {"192.0.2.0/24", "198.51.100.0/24"},
+		}))
+	})
+
+	It("should subtract the denylist for ingress controllers without deny support and report findings once", func() {
+		subtractClass := &networkingv1.IngressClass{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "no-deny",
+				Annotations: map[string]string{AnnotationSubtractDenylist: "true"},
+			},
+		}
+		Expect(k8sClient.Create(ctx, subtractClass)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, subtractClass)).To(Succeed()) }()
+
+		ingress := newTestIngress("subtract-app", map[string]string{
+			AnnotationWhitelist: "10.0.0.0/23",
+			AnnotationDenylist:  "10.0.1.0/24,192.0.2.0/24",
+		})
+		className := subtractClass.Name
+		ingress.Spec.IngressClassName = &className
+		Expect(k8sClient.Create(ctx, ingress)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, ingress)).To(Succeed()) }()
+
+		recorder := record.NewFakeRecorder(10)
+		ingressKey := types.NamespacedName{Name: ingress.Name, Namespace: ingress.Namespace}
+		controllerReconciler := &IngressReconciler{
+			Client: k8sClient,
+			Scheme: k8sClient.Scheme(),
+			Access: AccessConfig{Recorder: recorder},
+		}
+		_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: ingressKey})
+		Expect(err).NotTo(HaveOccurred())
+
+		updated := &networkingv1.Ingress{}
+		Expect(k8sClient.Get(ctx, ingressKey, updated)).To(Succeed())
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxWhitelist, "10.0.0.0/24"))
+		Expect(updated.Annotations).NotTo(HaveKey(AnnotationNginxDenylist))
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationAccessFindings,
+			"DenylistHoles=10.0.1.0/24;UnreachableDenyEntries=192.0.2.0/24"))
+		Expect(recorder.Events).To(HaveLen(2))
+
+		// Unchanged findings are not reported again
+		for range 2 {
+			<-recorder.Events
+		}
+		_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: ingressKey})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(recorder.Events).To(BeEmpty())
+	})
+
+	It("should deny all access with an event when the subtracted denylist covers the allowlist", func() {
+		subtractClass := &networkingv1.IngressClass{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "no-deny-covered",
+				Annotations: map[string]string{AnnotationSubtractDenylist: "true"},
+			},
+		}
+		Expect(k8sClient.Create(ctx, subtractClass)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, subtractClass)).To(Succeed()) }()
+
+		ingress := newTestIngress("covered-app", map[string]string{
+			AnnotationWhitelist: "10.0.1.0/24",
+			AnnotationDenylist:  "10.0.0.0/16",
+		})
+		className := subtractClass.Name
+		ingress.Spec.IngressClassName = &className
+		Expect(k8sClient.Create(ctx, ingress)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, ingress)).To(Succeed()) }()
+
+		recorder := record.NewFakeRecorder(10)
+		ingressKey := types.NamespacedName{Name: ingress.Name, Namespace: ingress.Namespace}
+		controllerReconciler := &IngressReconciler{
+			Client: k8sClient,
+			Scheme: k8sClient.Scheme(),
+			Access: AccessConfig{Recorder: recorder},
+		}
+		_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: ingressKey})
+		Expect(err).NotTo(HaveOccurred())
+
+		updated := &networkingv1.Ingress{}
+		Expect(k8sClient.Get(ctx, ingressKey, updated)).To(Succeed())
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxWhitelist, "0.0.0.0/32,::/128"))
+		Expect(updated.Annotations).NotTo(HaveKey(AnnotationNginxDenylist))
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationDenyAll, denyAllDenylistCovers))
+		Eventually(recorder.Events).Should(Receive(ContainSubstring(EventReasonDenyAll)))
+	})
+})
@@ -0,0 +1,141 @@
+package controller
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	v1 "k8s.io/api/networking/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	ingressnetworkpoliciesv1 "github.com/vitistack/ingressnetworkpolicy-operator/api/v1"
+)
+
+// maxOverlappingWindows bounds the windows merged when windows of a schedule overlap.
+const maxOverlappingWindows = 1000
+
+// +kubebuilder:rbac:groups=ingressnetworkpolicies.vitistack.io,resources=accessschedules,verbs=get;list;watch
+
+// scheduleOpen reports whether a window of the referenced AccessSchedule is open,
+// and records the next opening or closing of a window as a refresh of the list.
+// References to missing or invalid schedules are never open in allowlists, and always open in denylists,
+// so they never grant access.
+func (l *cidrList) scheduleOpen(ctx context.Context, r Getter, ingress *v1.Ingress, namespace string, reference string) bool {
+	log := logf.FromContext(ctx)
+
+	schedule, err := getAccessSchedule(ctx, r, namespace, reference)
+	if err != nil {
+		log.Error(err, "unable to resolve schedule for Ingress", "Ingress.Name", ingress.Name, "Schedule", reference, "Denylist", l.deny)
+		return l.deny
+	}
+
+	open, transition, err := accessWindow(schedule.Spec, time.Now())
+	if err != nil {
+		log.Error(err, "invalid schedule for Ingress", "Ingress.Name", ingress.Name, "Schedule", reference, "Denylist", l.deny)
+		return l.deny
+	}
+
+	l.refreshBy(transition)
+	return open
+}
+
+// getAccessSchedule fetches the AccessSchedule referenced as ns/name or name.
+// Only schedules in the Ingress namespace or the default namespace may be referenced.
+func getAccessSchedule(ctx context.Context, r Getter, ingressNamespace string, reference string) (*ingressnetworkpoliciesv1.AccessSchedule, error) {
+	namespace, name, ok := parseObjectReference(reference)
+	if !ok {
+		return nil, fmt.Errorf("invalid schedule reference %q, expected namespace/name", reference)
+	}
+
+	if namespace != ingressNamespace && namespace != DefaultNamespace {
+		return nil, fmt.Errorf("schedule %s/%s is outside namespace %s and %s", namespace, name, ingressNamespace, DefaultNamespace)
+	}
+
+	schedule := &ingressnetworkpoliciesv1.AccessSchedule{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, schedule); err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// accessWindow reports whether a window of the schedule is open at the given time,
+// and returns when it closes, or when the next window opens. Overlapping windows are merged.
+// The returned time is zero when the schedule never opens again.
+func accessWindow(spec ingressnetworkpoliciesv1.AccessScheduleSpec, now time.Time) (bool, time.Time, error) {
+	schedule, err := cron.ParseStandard(spec.Cron)
+	if err != nil {
+		return false, time.Time{}, err
+	}
+
+	location, err := time.LoadLocation(cmp.Or(spec.TimeZone, "UTC"))
+	if err != nil {
+		return false, time.Time{}, err
+	}
+
+	duration := spec.Duration.Duration
+	if duration <= 0 {
+		return false, time.Time{}, errors.New("duration must be positive")
+	}
+
+	// The first window opening after now-duration is the only one that may be open now
+	now = now.In(location)
+	start := schedule.Next(now.Add(-duration))
+	if start.IsZero() || start.After(now) {
+		return false, start, nil
+	}
+
+	end := start.Add(duration)
+	for range maxOverlappingWindows {
+		next := schedule.Next(start)
+		if next.IsZero() || next.After(end) {
+			break
+		}
+		start, end = next, next.Add(duration)
+	}
+
+	return true, end, nil
+}
+
+// ingressUsesSchedule reports whether a reference or entry in the annotations of an Ingress, or an entry of a CIDRSet
+// it references, is limited to the given AccessSchedule.
+func ingressUsesSchedule(ctx context.Context, r Getter, annotations map[string]string, namespace string, name string) bool {
+	matches := func(reference string) bool {
+		scheduleNamespace, scheduleName, ok := parseObjectReference(reference)
+		return ok && scheduleNamespace == namespace && scheduleName == name
+	}
+
+	for _, annotation := range append([]string{AnnotationWhitelist, AnnotationDenylist}, ingressReferenceAnnotations...) {
+		for _, reference := range filterSliceFromString(strings.Split(annotations[annotation], ",")) {
+			value, _, _ := strings.Cut(reference, "@")
+			value, schedule := splitScheduleReference(value)
+			if schedule != "" && matches(schedule) {
+				return true
+			}
+
+			// Entries of CIDRSets may be limited to the schedule too
+			kind, path := splitSourceReference(value)
+			if kind != SourceCIDRSet {
+				continue
+			}
+			setNamespace, setName, ok := parseObjectReference(path)
+			if !ok {
+				continue
+			}
+			set := ingressnetworkpoliciesv1.CIDRSet{}
+			if err := r.Get(ctx, client.ObjectKey{Namespace: setNamespace, Name: setName}, &set); err != nil {
+				continue
+			}
+			for _, entry := range set.Spec.Entries {
+				if entry.Schedule != "" && matches(entry.Schedule) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,168 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	ingressnetworkpoliciesv1 "github.com/vitistack/ingressnetworkpolicy-operator/api/v1"
+)
+
+var _ = Describe("Access schedules", func() {
+	ctx := context.Background()
+
+	var oslo *time.Location
+
+	BeforeEach(func() {
+		var err error
+		oslo, err = time.LoadLocation("Europe/Oslo")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	saturdayNights := ingressnetworkpoliciesv1.AccessScheduleSpec{
+		Cron:     "0 22 * * SAT",
+		Duration: metav1.Duration{Duration: 4 * time.Hour},
+		TimeZone: "Europe/Oslo",
+	}
+
+	It("should compute the next transition of a window in its time zone", func() {
+		open, transition, err := accessWindow(saturdayNights, time.Date(2026, 10, 17, 21, 0, 0, 0, oslo))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(open).To(BeFalse())
+		Expect(transition).To(BeTemporally("==", time.Date(2026, 10, 17, 22, 0, 0, 0, oslo)))
+
+		// The window spans midnight and closes on sunday
+		open, transition, err = accessWindow(saturdayNights, time.Date(2026, 10, 18, 1, 0, 0, 0, oslo))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(open).To(BeTrue())
+		Expect(transition).To(BeTemporally("==", time.Date(2026, 10, 18, 2, 0, 0, 0, oslo)))
+
+		open, transition, err = accessWindow(saturdayNights, time.Date(2026, 10, 18, 2, 0, 0, 0, oslo))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(open).To(BeFalse())
+		Expect(transition).To(BeTemporally("==", time.Date(2026, 10, 24, 22, 0, 0, 0, oslo)))
+	})
+
+	It("should merge overlapping windows", func() {
+		hourly := ingressnetworkpoliciesv1.AccessScheduleSpec{Cron: "0 * * * *", Duration: metav1.Duration{Duration: 90 * time.Minute}}
+		open, transition, err := accessWindow(hourly, time.Date(2026, 10, 17, 12, 10, 0, 0, time.UTC))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(open).To(BeTrue())
+		Expect(transition.After(time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC))).To(BeTrue())
+	})
+
+	It("should refuse invalid schedules", func() {
+		_, _, err := accessWindow(ingressnetworkpoliciesv1.AccessScheduleSpec{Cron: "every saturday", Duration: metav1.Duration{Duration: time.Hour}}, time.Now())
+		Expect(err).To(HaveOccurred())
+		_, _, err = accessWindow(ingressnetworkpoliciesv1.AccessScheduleSpec{Cron: "0 22 * * SAT", Duration: metav1.Duration{Duration: time.Hour}, TimeZone: "Mars/Olympus"}, time.Now())
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should only add scheduled references and entries while a window is open", func() {
+		ensureNamespace(ctx, DefaultNamespace)
+
+		always := &ingressnetworkpoliciesv1.AccessSchedule{
+			ObjectMeta: metav1.ObjectMeta{Name: "always", Namespace: DefaultNamespace},
+			Spec:       ingressnetworkpoliciesv1.AccessScheduleSpec{Cron: "* * * * *", Duration: metav1.Duration{Duration: 2 * time.Minute}},
+		}
+		never := &ingressnetworkpoliciesv1.AccessSchedule{
+			ObjectMeta: metav1.ObjectMeta{Name: "leap-day", Namespace: DefaultNamespace},
+			Spec:       ingressnetworkpoliciesv1.AccessScheduleSpec{Cron: "0 0 29 2 *", Duration: metav1.Duration{Duration: time.Minute}},
+		}
+		set := &ingressnetworkpoliciesv1.CIDRSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "maintenance-partners", Namespace: DefaultNamespace},
+			Spec: ingressnetworkpoliciesv1.CIDRSetSpec{Entries: []ingressnetworkpoliciesv1.CIDRSetEntry{
+				{CIDR: "198.51.100.0/24", Schedule: "always"},
+				{CIDR: "198.51.101.0/24", Schedule: "leap-day"},
+			}},
+		}
+		Expect(k8sClient.Create(ctx, always)).To(Succeed())
+		Expect(k8sClient.Create(ctx, never)).To(Succeed())
+		Expect(k8sClient.Create(ctx, set)).To(Succeed())
+		defer func() {
+			Expect(k8sClient.Delete(ctx, always)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, never)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, set)).To(Succeed())
+		}()
+
+		ingress := newTestIngress("scheduled-app", map[string]string{
+			AnnotationWhiteListNetworkPolicy: "cidrset:maintenance-partners",
+			AnnotationWhitelist:              "203.0.113.0/24~always,203.0.114.0/24~leap-day,10.0.0.0/24",
+		})
+		Expect(k8sClient.Create(ctx, ingress)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, ingress)).To(Succeed()) }()
+
+		ingressKey := types.NamespacedName{Name: ingress.Name, Namespace: ingress.Namespace}
+		controllerReconciler := &IngressReconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+		result, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: ingressKey})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+		Expect(result.RequeueAfter).To(BeNumerically("<", 24*time.Hour))
+
+		updated := &networkingv1.Ingress{}
+		Expect(k8sClient.Get(ctx, ingressKey, updated)).To(Succeed())
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxWhitelist, "10.0.0.0/24,198.51.100.0/24,203.0.113.0/24"))
+
+		Expect(controllerReconciler.ingressesUsingSchedule(ctx, never)).To(ConsistOf(
+			reconcile.Request{NamespacedName: ingressKey},
+		))
+	})
+
+	It("should deny all access outside the windows, and keep deny entries with unresolvable schedules", func() {
+		ensureNamespace(ctx, DefaultNamespace)
+
+		never := &ingressnetworkpoliciesv1.AccessSchedule{
+			ObjectMeta: metav1.ObjectMeta{Name: "leap-day-only", Namespace: DefaultNamespace},
+			Spec:       ingressnetworkpoliciesv1.AccessScheduleSpec{Cron: "0 0 29 2 *", Duration: metav1.Duration{Duration: time.Minute}},
+		}
+		invalid := &ingressnetworkpoliciesv1.AccessSchedule{
+			ObjectMeta: metav1.ObjectMeta{Name: "invalid", Namespace: DefaultNamespace},
+			Spec:       ingressnetworkpoliciesv1.AccessScheduleSpec{Cron: "every saturday", Duration: metav1.Duration{Duration: time.Hour}},
+		}
+		Expect(k8sClient.Create(ctx, never)).To(Succeed())
+		Expect(k8sClient.Create(ctx, invalid)).To(Succeed())
+		defer func() {
+			Expect(k8sClient.Delete(ctx, never)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, invalid)).To(Succeed())
+		}()
+
+		ingress := newTestIngress("closed-window-app", map[string]string{
+			AnnotationWhitelist: "203.0.113.0/24~leap-day-only,203.0.114.0/24~invalid",
+			AnnotationDenylist:  "198.51.100.0/24~invalid,198.51.101.0/24~missing",
+		})
+		Expect(k8sClient.Create(ctx, ingress)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, ingress)).To(Succeed()) }()
+
+		ingressKey := types.NamespacedName{Name: ingress.Name, Namespace: ingress.Namespace}
+		controllerReconciler := &IngressReconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+		_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: ingressKey})
+		Expect(err).NotTo(HaveOccurred())
+
+		updated := &networkingv1.Ingress{}
+		Expect(k8sClient.Get(ctx, ingressKey, updated)).To(Succeed())
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxWhitelist, "0.0.0.0/32,::/128"))
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxDenylist, "198.51.100.0/24,198.51.101.0/24"))
+	})
+})
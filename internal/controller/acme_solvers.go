@@ -0,0 +1,98 @@
+package controller
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	v1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ACMEPolicy is how the operator handles the HTTP-01 challenges of cert-manager, which the certificate
+// authority validates from addresses outside the allowlists of the Ingresses.
+type ACMEPolicy struct {
+	// Mode is ACMEPolicyExempt to leave solver Ingresses without access lists, or ACMEPolicyCARanges
+	// to only allow the CA validation ranges on them. Solver Ingresses are exempt when unset.
+	Mode string
+	// CARanges are the validation ranges of the certificate authority. They are allowed on solver Ingresses
+	// with ACMEPolicyCARanges, and on restricted Ingresses while cert-manager adds challenge paths to them.
+	CARanges []string
+}
+
+// Validate checks the mode and the CA validation ranges of the policy.
+func (p ACMEPolicy) Validate() error {
+	switch p.Mode {
+	case "", ACMEPolicyExempt:
+	case ACMEPolicyCARanges:
+		if len(p.CARanges) == 0 {
+			return fmt.Errorf("the ACME policy %s requires CA validation ranges", ACMEPolicyCARanges)
+		}
+	default:
+		return fmt.Errorf("unknown ACME policy %q, expected %s or %s", p.Mode, ACMEPolicyExempt, ACMEPolicyCARanges)
+	}
+
+	for _, cidr := range p.CARanges {
+		if !checkValidCIDR(cidr) {
+			return fmt.Errorf("invalid CA validation range %q", cidr)
+		}
+	}
+	return nil
+}
+
+// solverAllowlist returns the allowlist of solver Ingresses, empty when they are exempt.
+func (p ACMEPolicy) solverAllowlist() []string {
+	if p.Mode != ACMEPolicyCARanges {
+		return nil
+	}
+	return sortSlice(p.CARanges)
+}
+
+// ingressIsACMESolver reports whether the Ingress was created by cert-manager to solve an HTTP-01 challenge:
+// it is labelled as solver, owned by a cert-manager Challenge and only serves challenge paths. Since the label
+// alone can be set by anyone able to edit the Ingress, Ingresses serving other paths are never solvers.
+func ingressIsACMESolver(obj client.Object) bool {
+	if obj.GetLabels()[LabelACMESolver] != "true" {
+		return false
+	}
+	if !slices.ContainsFunc(obj.GetOwnerReferences(), func(owner metav1.OwnerReference) bool {
+		return owner.Kind == ACMEChallengeKind && strings.HasPrefix(owner.APIVersion, ACMEGroup+"/")
+	}) {
+		return false
+	}
+
+	ingress, ok := obj.(*v1.Ingress)
+	if !ok || ingress.Spec.DefaultBackend != nil {
+		return false
+	}
+	var paths int
+	for _, rule := range ingress.Spec.Rules {
+		if rule.HTTP == nil {
+			return false
+		}
+		for _, path := range rule.HTTP.Paths {
+			if !strings.HasPrefix(path.Path, ACMEChallengePathPrefix) {
+				return false
+			}
+			paths++
+		}
+	}
+	return paths > 0
+}
+
+// ingressHasACMEChallenge reports whether cert-manager added a challenge path to the Ingress,
+// as it does for issuers solving HTTP-01 challenges by editing the Ingress in place.
+func ingressHasACMEChallenge(ingress *v1.Ingress) bool {
+	for _, rule := range ingress.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for _, path := range rule.HTTP.Paths {
+			if strings.HasPrefix(path.Path, ACMEChallengePathPrefix) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ingressnetworkpoliciesv1 "github.com/vitistack/ingressnetworkpolicy-operator/api/v1"
+)
+
+var _ = Describe("cert-manager solvers", func() {
+	ctx := context.Background()
+
+	caRanges := ACMEPolicy{Mode: ACMEPolicyCARanges, CARanges: []string{"198.51.100.0/24", "192.0.2.0/24"}}
+
+	reconcileIngress := func(policy ACMEPolicy, ingress *networkingv1.Ingress) *networkingv1.Ingress {
+		reconciler := &IngressReconciler{Client: k8sClient, Scheme: k8sClient.Scheme(), Access: AccessConfig{ACME: policy}}
+		return reconcileAndGet(reconciler, client.ObjectKeyFromObject(ingress))
+	}
+
+	// newSolver returns a solver Ingress as created by cert-manager for a Challenge.
+	newSolver := func(name string, annotations map[string]string) *networkingv1.Ingress {
+		solver := newTestIngress(name, annotations)
+		solver.Labels = map[string]string{LabelACMESolver: "true"}
+		solver.OwnerReferences = []metav1.OwnerReference{{
+			APIVersion: ACMEGroup + "/v1", Kind: ACMEChallengeKind, Name: name, UID: types.UID(name),
+		}}
+		solver.Spec.Rules[0].HTTP.Paths[0].Path = ACMEChallengePathPrefix + "token"
+		return solver
+	}
+
+	BeforeEach(func() {
+		ensureNamespace(ctx, "default")
+	})
+
+	It("should validate the ACME policy", func() {
+		Expect(ACMEPolicy{}.Validate()).To(Succeed())
+		Expect(caRanges.Validate()).To(Succeed())
+		Expect(ACMEPolicy{Mode: ACMEPolicyCARanges}.Validate()).To(MatchError(ContainSubstring("requires CA validation ranges")))
+		Expect(ACMEPolicy{Mode: "allow"}.Validate()).To(MatchError(ContainSubstring(`unknown ACME policy "allow"`)))
+		Expect(ACMEPolicy{CARanges: []string{"198.51.100.0"}}.Validate()).To(MatchError(ContainSubstring("invalid CA validation range")))
+	})
+
+	It("should exempt solver Ingresses or only allow the CA validation ranges", func() {
+		solver := newSolver("cm-acme-http-solver-abcde", map[string]string{AnnotationWhitelist: "10.50.0.0/16"})
+		Expect(k8sClient.Create(ctx, solver)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, solver)).To(Succeed()) }()
+
+		updated := reconcileIngress(ACMEPolicy{}, solver)
+		Expect(updated.Annotations).NotTo(HaveKey(AnnotationNginxWhitelist))
+
+		updated = reconcileIngress(caRanges, solver)
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxWhitelist, "192.0.2.0/24,198.51.100.0/24"))
+
+		// Lists written before are removed when solvers are exempt
+		updated = reconcileIngress(ACMEPolicy{}, solver)
+		Expect(updated.Annotations).NotTo(HaveKey(AnnotationNginxWhitelist))
+	})
+
+	It("should only treat Ingresses owned by a Challenge and serving challenge paths as solvers", func() {
+		Expect(ingressIsACMESolver(newSolver("solver", nil))).To(BeTrue())
+
+		labelled := newTestIngress("labelled", nil)
+		labelled.Labels = map[string]string{LabelACMESolver: "true"}
+		Expect(ingressIsACMESolver(labelled)).To(BeFalse())
+
+		notTrue := newSolver("not-true", nil)
+		notTrue.Labels[LabelACMESolver] = "false"
+		Expect(ingressIsACMESolver(notTrue)).To(BeFalse())
+
+		unowned := newSolver("unowned", nil)
+		unowned.OwnerReferences = nil
+		Expect(ingressIsACMESolver(unowned)).To(BeFalse())
+
+		otherPath := newSolver("other-path", nil)
+		path := otherPath.Spec.Rules[0].HTTP.Paths[0]
+		path.Path = "/"
+		otherPath.Spec.Rules[0].HTTP.Paths = append(otherPath.Spec.Rules[0].HTTP.Paths, path)
+		Expect(ingressIsACMESolver(otherPath)).To(BeFalse())
+	})
+
+	It("should keep the mandatory denylist on exempt solver Ingresses", func() {
+		mandatory := &ingressnetworkpoliciesv1.MandatoryDenylist{
+			ObjectMeta: metav1.ObjectMeta{Name: "acme-security"},
+			Spec:       ingressnetworkpoliciesv1.MandatoryDenylistSpec{Entries: []string{"203.0.113.0/24"}},
+		}
+		Expect(k8sClient.Create(ctx, mandatory)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, mandatory)).To(Succeed()) }()
+
+		solver := newSolver("cm-acme-http-solver-mandatory", nil)
+		Expect(k8sClient.Create(ctx, solver)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, solver)).To(Succeed()) }()
+
+		updated := reconcileIngress(ACMEPolicy{}, solver)
+		Expect(updated.Annotations).NotTo(HaveKey(AnnotationNginxWhitelist))
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxDenylist, "203.0.113.0/24"))
+
+		// A labelled Ingress serving other paths is restricted by its annotations
+		labelled := newTestIngress("acme-labelled-app", map[string]string{AnnotationWhitelist: "10.50.0.0/16"})
+		labelled.Labels = map[string]string{LabelACMESolver: "true"}
+		Expect(k8sClient.Create(ctx, labelled)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, labelled)).To(Succeed()) }()
+
+		updated = reconcileIngress(ACMEPolicy{}, labelled)
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxWhitelist, "10.50.0.0/16"))
+	})
+
+	It("should allow the CA validation ranges while a challenge path is added to a restricted Ingress", func() {
+		ingress := newTestIngress("acme-in-place-app", map[string]string{AnnotationWhitelist: "10.50.0.0/16"})
+		challenge := ingress.Spec.Rules[0].HTTP.Paths[0]
+		challenge.Path = ACMEChallengePathPrefix + "token"
+		ingress.Spec.Rules[0].HTTP.Paths = append(ingress.Spec.Rules[0].HTTP.Paths, challenge)
+		Expect(k8sClient.Create(ctx, ingress)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, ingress)).To(Succeed()) }()
+
+		updated := reconcileIngress(caRanges, ingress)
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxWhitelist, "10.50.0.0/16,192.0.2.0/24,198.51.100.0/24"))
+
+		// The ranges are removed with the challenge path
+		updated.Spec.Rules[0].HTTP.Paths = updated.Spec.Rules[0].HTTP.Paths[:1]
+		Expect(k8sClient.Update(ctx, updated)).To(Succeed())
+		updated = reconcileIngress(caRanges, updated)
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxWhitelist, "10.50.0.0/16"))
+	})
+})
@@ -0,0 +1,64 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var _ = Describe("AdminNetworkPolicy references", func() {
+	ctx := context.Background()
+
+	It("should report them as unresolved and deny all access when they are the only allow source", func() {
+		ingress := newTestIngress("anp-app", map[string]string{
+			AnnotationWhiteListNetworkPolicy: "anp:tenant-a,banp:default",
+			AnnotationDenyListNetworkPolicy:  "anp:blocked",
+		})
+		Expect(k8sClient.Create(ctx, ingress)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, ingress)).To(Succeed()) }()
+
+		recorder := record.NewFakeRecorder(10)
+		ingressKey := client.ObjectKeyFromObject(ingress)
+		controllerReconciler := &IngressReconciler{Client: k8sClient, Scheme: k8sClient.Scheme(), Access: AccessConfig{Recorder: recorder}}
+		_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: ingressKey})
+		Expect(err).NotTo(HaveOccurred())
+
+		updated := &networkingv1.Ingress{}
+		Expect(k8sClient.Get(ctx, ingressKey, updated)).To(Succeed())
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationUnresolvedSources, "anp:blocked,anp:tenant-a,banp:default"))
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxWhitelist, "0.0.0.0/32,::/128"))
+		Expect(updated.Annotations).NotTo(HaveKey(AnnotationNginxDenylist))
+		Expect(recorder.Events).To(Receive(And(ContainSubstring("Warning "+EventReasonUnresolvedSource), ContainSubstring("anp:tenant-a"))))
+		Expect(recorder.Events).To(Receive(ContainSubstring(EventReasonDenyAll)))
+
+		// Entries next to them keep applying
+		updated.Annotations[AnnotationWhitelist] = "192.0.2.0/24"
+		Expect(k8sClient.Update(ctx, updated)).To(Succeed())
+		_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: ingressKey})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(k8sClient.Get(ctx, ingressKey, updated)).To(Succeed())
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxWhitelist, "192.0.2.0/24"))
+		Expect(updated.Annotations).NotTo(HaveKey(AnnotationDenyAll))
+	})
+})
@@ -0,0 +1,112 @@
+package controller
+
+import (
+	"context"
+	"slices"
+	"sort"
+
+	v1 "k8s.io/api/networking/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// ingressIsCanary reports whether the Ingress is an ingress-nginx canary of another Ingress.
+func ingressIsCanary(obj client.Object) bool {
+	return obj.GetAnnotations()[AnnotationNginxCanary] == "true"
+}
+
+// ingressRoutes returns the hosts and paths served by the rules of the Ingress, f.ex app.example.com/api.
+func ingressRoutes(ingress *v1.Ingress) []string {
+	var routes []string
+	for _, rule := range ingress.Spec.Rules {
+		if rule.HTTP == nil {
+			routes = append(routes, rule.Host)
+			continue
+		}
+		for _, path := range rule.HTTP.Paths {
+			routes = append(routes, rule.Host+path.Path)
+		}
+	}
+	return sortSlice(routes)
+}
+
+// ingressesShareRoute reports whether the Ingresses serve a common host and path.
+func ingressesShareRoute(a *v1.Ingress, b *v1.Ingress) bool {
+	routes := ingressRoutes(b)
+	return slices.ContainsFunc(ingressRoutes(a), func(route string) bool { return slices.Contains(routes, route) })
+}
+
+// canaryPrimary returns the primary Ingress of the canary, a managed Ingress in its namespace serving
+// one of its hosts and paths. The first by name is used when several do. It returns nil when the
+// Ingress is no canary, or no managed Ingress serves its hosts and paths.
+func canaryPrimary(ctx context.Context, r client.Reader, ingress *v1.Ingress) (*v1.Ingress, error) {
+	if !ingressIsCanary(ingress) {
+		return nil, nil
+	}
+
+	ingressList := v1.IngressList{}
+	if err := r.List(ctx, &ingressList, client.InNamespace(ingress.Namespace)); err != nil {
+		return nil, err
+	}
+	sort.Slice(ingressList.Items, func(i, j int) bool { return ingressList.Items[i].Name < ingressList.Items[j].Name })
+
+	for _, candidate := range ingressList.Items {
+		if candidate.Name == ingress.Name || ingressIsCanary(&candidate) || !ingressManaged(&candidate) {
+			continue
+		}
+		if ingressesShareRoute(ingress, &candidate) {
+			return &candidate, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// canaryAccessSource returns a copy of the canary with the access annotations of its primary Ingress,
+// so the canary resolves to the allow- and denylist of the primary. The access annotations the canary
+// declares with other values than the primary are returned too, they are ignored.
+func canaryAccessSource(canary *v1.Ingress, primary *v1.Ingress) (*v1.Ingress, []string) {
+	source := canary.DeepCopy()
+
+	var differing []string
+	for _, annotation := range ingressAccessAnnotations {
+		value, declared := canary.Annotations[annotation]
+		if declared && value != primary.Annotations[annotation] {
+			differing = append(differing, annotation)
+		}
+
+		delete(source.Annotations, annotation)
+		if value, ok := primary.Annotations[annotation]; ok {
+			source.Annotations[annotation] = value
+		}
+	}
+
+	return source, differing
+}
+
+// canaryInherits reports whether the access lists of the Ingress are, or were, inherited from a primary Ingress.
+func canaryInherits(ctx context.Context, r client.Reader, ingress *v1.Ingress) bool {
+	if ingress.Annotations[AnnotationCanaryOf] != "" {
+		return true
+	}
+	primary, err := canaryPrimary(ctx, r, ingress)
+	return err != nil || primary != nil
+}
+
+// canariesOfIngress maps a changed Ingress to the canaries serving its hosts and paths, while it is managed,
+// and to the canaries having inherited its access lists. Since rendering the access lists updates the primary,
+// the canaries follow every change of the lists of their primary.
+func (r *IngressReconciler) canariesOfIngress(ctx context.Context, obj client.Object) []reconcile.Request {
+	primary, ok := obj.(*v1.Ingress)
+	if !ok || ingressIsCanary(primary) {
+		return nil
+	}
+
+	return r.ingressesMatching(ctx, func(obj client.Object) bool {
+		canary, ok := obj.(*v1.Ingress)
+		if !ok || !ingressIsCanary(canary) {
+			return false
+		}
+		return canary.Annotations[AnnotationCanaryOf] == primary.Name || (ingressManaged(primary) && ingressesShareRoute(canary, primary))
+	}, client.InNamespace(primary.Namespace))
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var _ = Describe("Canary Ingresses", func() {
+	ctx := context.Background()
+
+	// newCanary returns a canary serving the host and path of the primary Ingress
+	newCanary := func(primary *networkingv1.Ingress, annotations map[string]string) *networkingv1.Ingress {
+		canary := newTestIngress(primary.Name+"-canary", annotations)
+		canary.Annotations[AnnotationNginxCanary] = "true"
+		canary.Spec.Rules[0].Host = primary.Spec.Rules[0].Host
+		return canary
+	}
+
+	BeforeEach(func() {
+		ensureNamespace(ctx, "default")
+	})
+
+	It("should inherit the access lists of the primary Ingress", func() {
+		primary := newTestIngress("canary-app", map[string]string{
+			AnnotationWhitelist: "10.60.0.0/16",
+			AnnotationDenylist:  "10.60.1.0/24",
+		})
+		Expect(k8sClient.Create(ctx, primary)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, primary)).To(Succeed()) }()
+
+		canary := newCanary(primary, map[string]string{AnnotationWhitelist: "0.0.0.0/0"})
+		Expect(k8sClient.Create(ctx, canary)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, canary)).To(Succeed()) }()
+
+		recorder := record.NewFakeRecorder(10)
+		reconciler := &IngressReconciler{Client: k8sClient, Scheme: k8sClient.Scheme(), Access: AccessConfig{Recorder: recorder}}
+		reconcileAndGet(reconciler, client.ObjectKeyFromObject(primary))
+
+		updated := reconcileAndGet(reconciler, client.ObjectKeyFromObject(canary))
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxWhitelist, "10.60.0.0/16"))
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxDenylist, "10.60.1.0/24"))
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationCanaryOf, primary.Name))
+		Eventually(recorder.Events).Should(Receive(ContainSubstring("differing annotations " + AnnotationWhitelist)))
+
+		// Changes of the primary reach the canary
+		Expect(reconciler.canariesOfIngress(ctx, primary)).To(ConsistOf(reconcile.Request{NamespacedName: client.ObjectKeyFromObject(canary)}))
+
+		// Without a primary, the canary falls back to its own annotations
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(primary), primary)).To(Succeed())
+		primary.Spec.Rules[0].Host = "other.example.com"
+		Expect(k8sClient.Update(ctx, primary)).To(Succeed())
+		Expect(reconciler.canariesOfIngress(ctx, primary)).To(ConsistOf(reconcile.Request{NamespacedName: client.ObjectKeyFromObject(canary)}))
+
+		updated = reconcileAndGet(reconciler, client.ObjectKeyFromObject(canary))
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxWhitelist, "0.0.0.0/0"))
+		Expect(updated.Annotations).NotTo(HaveKey(AnnotationNginxDenylist))
+		Expect(updated.Annotations).NotTo(HaveKey(AnnotationCanaryOf))
+	})
+
+	It("should inherit the backend NetworkPolicies of the primary Ingress", func() {
+		appLabels := map[string]string{"app": "canary-inherit-app"}
+		service := &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "canary-inherit-app", Namespace: "default"},
+			Spec: corev1.ServiceSpec{
+				Selector: appLabels,
+				Ports:    []corev1.ServicePort{{Port: 80, TargetPort: intstr.FromInt32(8080)}},
+			},
+		}
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "canary-inherit-app", Namespace: "default", Labels: appLabels},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "app"}}},
+		}
+		policy := &networkingv1.NetworkPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "canary-inherit-app", Namespace: "default"},
+			Spec: networkingv1.NetworkPolicySpec{
+				PodSelector: metav1.LabelSelector{MatchLabels: appLabels},
+				Ingress: []networkingv1.NetworkPolicyIngressRule{{
+					From: []networkingv1.NetworkPolicyPeer{{IPBlock: &networkingv1.IPBlock{CIDR: "10.61.0.0/16"}}},
+				}},
+			},
+		}
+		Expect(k8sClient.Create(ctx, service)).To(Succeed())
+		Expect(k8sClient.Create(ctx, pod)).To(Succeed())
+		Expect(k8sClient.Create(ctx, policy)).To(Succeed())
+		defer func() {
+			Expect(k8sClient.Delete(ctx, policy)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, pod)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, service)).To(Succeed())
+		}()
+
+		primary := newTestIngress("canary-inherit-app", map[string]string{AnnotationInheritBackendPolicies: "true"})
+		Expect(k8sClient.Create(ctx, primary)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, primary)).To(Succeed()) }()
+
+		// The canary routes to its own backend Service, which no NetworkPolicy selects
+		canary := newCanary(primary, map[string]string{})
+		Expect(k8sClient.Create(ctx, canary)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, canary)).To(Succeed()) }()
+
+		reconciler := &IngressReconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+		reconcileAndGet(reconciler, client.ObjectKeyFromObject(primary))
+
+		updated := reconcileAndGet(reconciler, client.ObjectKeyFromObject(canary))
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxWhitelist, "10.61.0.0/16"))
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationInheritedPolicies, policy.Name))
+	})
+
+	It("should leave canaries of unmanaged Ingresses untouched", func() {
+		primary := newTestIngress("canary-unmanaged-app", map[string]string{AnnotationNginxWhitelist: "192.0.2.0/24"})
+		Expect(k8sClient.Create(ctx, primary)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, primary)).To(Succeed()) }()
+
+		canary := newCanary(primary, map[string]string{AnnotationNginxWhitelist: "192.0.2.0/24"})
+		Expect(k8sClient.Create(ctx, canary)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, canary)).To(Succeed()) }()
+
+		reconciler := &IngressReconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+		Expect(canaryInherits(ctx, k8sClient, canary)).To(BeFalse())
+		Expect(reconciler.canariesOfIngress(ctx, primary)).To(BeEmpty())
+	})
+})
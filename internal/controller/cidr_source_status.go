@@ -0,0 +1,105 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	ingressnetworkpoliciesv1 "github.com/vitistack/ingressnetworkpolicy-operator/api/v1"
+)
+
+// defaultSourceRefreshInterval is used for sources without a refresh interval.
+const defaultSourceRefreshInterval = time.Hour
+
+// refreshCIDRSource fetches the prefixes of a source when its refresh interval has passed or its spec changed,
+// keeping the last good prefixes in the status when the fetch fails, and updates the Ready and Stale conditions.
+// It returns the time until the next fetch, or until the prefixes go stale if that happens first.
+func refreshCIDRSource(ctx context.Context, status *ingressnetworkpoliciesv1.CIDRSourceStatus, generation int64, refreshInterval time.Duration, maxAge *metav1.Duration, fetch func() ([]string, error)) time.Duration {
+	log := logf.FromContext(ctx)
+
+	now := time.Now()
+
+	if refreshInterval <= 0 {
+		refreshInterval = defaultSourceRefreshInterval
+	}
+
+	due := status.LastFetchTime == nil ||
+		status.ObservedGeneration != generation ||
+		!now.Before(status.LastFetchTime.Add(refreshInterval))
+
+	if due {
+		prefixes, err := fetch()
+		status.LastFetchTime = &metav1.Time{Time: now}
+		status.ObservedGeneration = generation
+
+		if err != nil {
+			log.Error(err, "unable to fetch prefixes, keeping last good prefixes")
+			meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+				Type:    ingressnetworkpoliciesv1.CIDRSourceConditionReady,
+				Status:  metav1.ConditionFalse,
+				Reason:  "FetchFailed",
+				Message: err.Error(),
+			})
+		} else {
+			status.Prefixes = prefixes
+			status.LastSuccessfulFetchTime = &metav1.Time{Time: now}
+			meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+				Type:    ingressnetworkpoliciesv1.CIDRSourceConditionReady,
+				Status:  metav1.ConditionTrue,
+				Reason:  "Fetched",
+				Message: fmt.Sprintf("%d prefixes fetched", len(prefixes)),
+			})
+		}
+	}
+
+	stale, staleAt := cidrSourceStale(status, maxAge, now)
+	staleCondition := metav1.Condition{
+		Type:    ingressnetworkpoliciesv1.CIDRSourceConditionStale,
+		Status:  metav1.ConditionFalse,
+		Reason:  "Fresh",
+		Message: "prefixes are within max age",
+	}
+	if stale {
+		staleCondition.Status = metav1.ConditionTrue
+		staleCondition.Reason = "MaxAgeExceeded"
+		staleCondition.Message = "prefixes are older than max age and not used"
+	}
+	meta.SetStatusCondition(&status.Conditions, staleCondition)
+
+	// Requeue at the next fetch, or when the data goes stale if that happens first
+	requeueAfter := status.LastFetchTime.Add(refreshInterval).Sub(now)
+	if !stale && !staleAt.IsZero() && staleAt.Sub(now) < requeueAfter {
+		requeueAfter = staleAt.Sub(now)
+	}
+
+	return requeueAfter
+}
+
+// cidrSourceStale reports whether the last good prefixes of a source are older than its max age,
+// and when they go stale. Sources without a max age never go stale.
+func cidrSourceStale(status *ingressnetworkpoliciesv1.CIDRSourceStatus, maxAge *metav1.Duration, now time.Time) (bool, time.Time) {
+	if maxAge == nil || status.LastSuccessfulFetchTime == nil {
+		return false, time.Time{}
+	}
+
+	staleAt := status.LastSuccessfulFetchTime.Add(maxAge.Duration)
+	return !now.Before(staleAt), staleAt
+}
+
+// cidrSourcePrefixes returns the last good prefixes of a source,
+// failing when the source was never fetched or has gone stale.
+func cidrSourcePrefixes(kind string, namespace string, name string, status *ingressnetworkpoliciesv1.CIDRSourceStatus, maxAge *metav1.Duration) ([]string, error) {
+	if status.LastSuccessfulFetchTime == nil {
+		return nil, fmt.Errorf("%s %s/%s has not been fetched", kind, namespace, name)
+	}
+
+	if stale, staleAt := cidrSourceStale(status, maxAge, time.Now()); stale {
+		return nil, fmt.Errorf("%s %s/%s is stale since %s", kind, namespace, name, staleAt.Format(time.RFC3339))
+	}
+
+	return status.Prefixes, nil
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	ingressnetworkpoliciesv1 "github.com/vitistack/ingressnetworkpolicy-operator/api/v1"
+)
+
+// CIDRFeedReconciler reconciles a CIDRFeed object
+type CIDRFeedReconciler struct {
+	client.Client
+	Scheme     *runtime.Scheme
+	HTTPClient *http.Client
+}
+
+// +kubebuilder:rbac:groups=ingressnetworkpolicies.vitistack.io,resources=cidrfeeds,verbs=get;list;watch
+// +kubebuilder:rbac:groups=ingressnetworkpolicies.vitistack.io,resources=cidrfeeds/status,verbs=get;update;patch
+
+// Reconcile fetches the feed when its refresh interval has passed or its spec changed,
+// and keeps the last good prefixes in the status when the fetch fails.
+// Ingresses referencing the feed are updated through the status change.
+func (r *CIDRFeedReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := logf.FromContext(ctx)
+
+	var feed ingressnetworkpoliciesv1.CIDRFeed
+	if err := r.Get(ctx, req.NamespacedName, &feed); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	original := feed.Status.DeepCopy()
+
+	httpClient := r.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	requeueAfter := refreshCIDRSource(ctx, &feed.Status, feed.Generation, feed.Spec.RefreshInterval.Duration, feed.Spec.MaxAge, func() ([]string, error) {
+		log.Info("Fetching CIDRFeed", "CIDRFeed.Name", feed.Name, "URL", feed.Spec.URL)
+		return fetchCIDRFeed(ctx, r, httpClient, &feed)
+	})
+
+	if !equality.Semantic.DeepEqual(original, &feed.Status) {
+		if err := r.Status().Update(ctx, &feed); err != nil {
+			log.Error(err, "unable to update CIDRFeed status", "CIDRFeed.Name", feed.Name)
+			return ctrl.Result{}, err
+		}
+	}
+
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *CIDRFeedReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&ingressnetworkpoliciesv1.CIDRFeed{}).
+		Named("cidrfeed").
+		Complete(r)
+}
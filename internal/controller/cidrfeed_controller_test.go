@@ -0,0 +1,213 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	ingressnetworkpoliciesv1 "github.com/vitistack/ingressnetworkpolicy-operator/api/v1"
+)
+
+var _ = Describe("CIDRFeed Controller", func() {
+	ctx := context.Background()
+
+	It("should parse text, json and csv feeds", func() {
+		text := ingressnetworkpoliciesv1.CIDRFeedSpec{Format: ingressnetworkpoliciesv1.CIDRFeedFormatText}
+		Expect(parseCIDRFeed(text, []byte("# vpn\n10.0.0.0/24\n192.0.2.7\n"))).To(Equal([]string{"10.0.0.0/24", "192.0.2.7"}))
+
+		json := ingressnetworkpoliciesv1.CIDRFeedSpec{
+			Format:   ingressnetworkpoliciesv1.CIDRFeedFormatJSON,
+			JSONPath: `.prefixes[?(@.service=="CDN")].ip_prefix`,
+		}
+		document := `{"prefixes":[{"ip_prefix":"198.51.100.0/24","service":"CDN"},{"ip_prefix":"203.0.113.0/24","service":"EC2"}]}`
+		Expect(parseCIDRFeed(json, []byte(document))).To(Equal([]string{"198.51.100.0/24"}))
+
+		csv := ingressnetworkpoliciesv1.CIDRFeedSpec{Format: ingressnetworkpoliciesv1.CIDRFeedFormatCSV, CSVColumn: 1}
+		entries, err := parseCIDRFeed(csv, []byte("site,prefix\noslo,10.1.0.0/16\n# closed\nbergen, 10.2.0.0/16\nshort\n"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(Equal([]string{"prefix", "10.1.0.0/16", "10.2.0.0/16"}))
+
+		prefix, ok := normalizePrefix("192.0.2.7")
+		Expect(ok).To(BeTrue())
+		Expect(prefix).To(Equal("192.0.2.7/32"))
+		prefix, ok = normalizePrefix("2001:db8::1")
+		Expect(ok).To(BeTrue())
+		Expect(prefix).To(Equal("2001:db8::1/128"))
+		_, ok = normalizePrefix("prefix")
+		Expect(ok).To(BeFalse())
+	})
+
+	It("should fetch the feed with credentials and keep the last good prefixes", func() {
+		var failing atomic.Bool
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer s3cret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			if failing.Load() {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			_, _ = w.Write([]byte("10.70.0.0/24\n10.71.0.0/24\n"))
+		}))
+		defer server.Close()
+
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "feed-auth", Namespace: "default"},
+			Data:       map[string][]byte{"token": []byte("s3cret\n")},
+		}
+		Expect(k8sClient.Create(ctx, secret)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, secret)).To(Succeed()) }()
+
+		feed := &ingressnetworkpoliciesv1.CIDRFeed{
+			ObjectMeta: metav1.ObjectMeta{Name: "vpn", Namespace: "default"},
+			Spec: ingressnetworkpoliciesv1.CIDRFeedSpec{
+				URL:             server.URL,
+				Format:          ingressnetworkpoliciesv1.CIDRFeedFormatText,
+				RefreshInterval: metav1.Duration{Duration: time.Hour},
+				AuthSecretRef:   &ingressnetworkpoliciesv1.SecretAuthReference{Name: "feed-auth"},
+			},
+		}
+		Expect(k8sClient.Create(ctx, feed)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, feed)).To(Succeed()) }()
+
+		feedKey := types.NamespacedName{Name: feed.Name, Namespace: feed.Namespace}
+		feedReconciler := &CIDRFeedReconciler{Client: k8sClient, Scheme: k8sClient.Scheme(), HTTPClient: server.Client()}
+		result, err := feedReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: feedKey})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(BeNumerically("~", time.Hour, time.Minute))
+
+		Expect(k8sClient.Get(ctx, feedKey, feed)).To(Succeed())
+		Expect(feed.Status.Prefixes).To(Equal([]string{"10.70.0.0/24", "10.71.0.0/24"}))
+		Expect(meta.IsStatusConditionTrue(feed.Status.Conditions, ingressnetworkpoliciesv1.CIDRSourceConditionReady)).To(BeTrue())
+
+		ingress := newTestIngress("feed-app", map[string]string{
+			AnnotationWhiteListNetworkPolicy: "feed:default/vpn",
+		})
+		Expect(k8sClient.Create(ctx, ingress)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, ingress)).To(Succeed()) }()
+
+		ingressKey := types.NamespacedName{Name: ingress.Name, Namespace: ingress.Namespace}
+		ingressReconciler := &IngressReconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+		_, err = ingressReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: ingressKey})
+		Expect(err).NotTo(HaveOccurred())
+
+		updated := &networkingv1.Ingress{}
+		Expect(k8sClient.Get(ctx, ingressKey, updated)).To(Succeed())
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxWhitelist, "10.70.0.0/24,10.71.0.0/24"))
+		Expect(ingressReconciler.ingressesForSource(SourceFeed)(ctx, feed)).To(ConsistOf(
+			reconcile.Request{NamespacedName: ingressKey},
+		))
+
+		// A failing fetch keeps the last good prefixes
+		failing.Store(true)
+		feed.Status.LastFetchTime = &metav1.Time{Time: time.Now().Add(-2 * time.Hour)}
+		Expect(k8sClient.Status().Update(ctx, feed)).To(Succeed())
+
+		_, err = feedReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: feedKey})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(k8sClient.Get(ctx, feedKey, feed)).To(Succeed())
+		Expect(feed.Status.Prefixes).To(Equal([]string{"10.70.0.0/24", "10.71.0.0/24"}))
+		Expect(meta.IsStatusConditionFalse(feed.Status.Conditions, ingressnetworkpoliciesv1.CIDRSourceConditionReady)).To(BeTrue())
+	})
+
+	It("should stop using prefixes older than max age", func() {
+		feed := &ingressnetworkpoliciesv1.CIDRFeed{
+			ObjectMeta: metav1.ObjectMeta{Name: "stale", Namespace: "default"},
+			Spec: ingressnetworkpoliciesv1.CIDRFeedSpec{
+				URL:    "http://127.0.0.1:1/feed",
+				MaxAge: &metav1.Duration{Duration: time.Hour},
+			},
+			Status: ingressnetworkpoliciesv1.CIDRSourceStatus{
+				Prefixes:                []string{"10.80.0.0/24"},
+				LastSuccessfulFetchTime: &metav1.Time{Time: time.Now().Add(-30 * time.Minute)},
+			},
+		}
+		ingress := newTestIngress("stale-app", nil)
+
+		stale, staleAt := cidrSourceStale(&feed.Status, feed.Spec.MaxAge, time.Now())
+		Expect(stale).To(BeFalse())
+		Expect(staleAt).To(BeTemporally("~", time.Now().Add(30*time.Minute), time.Minute))
+
+		Expect(k8sClient.Create(ctx, feed)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, feed)).To(Succeed()) }()
+		feed.Status.LastSuccessfulFetchTime = &metav1.Time{Time: time.Now().Add(-2 * time.Hour)}
+		Expect(k8sClient.Status().Update(ctx, feed)).To(Succeed())
+
+		_, err := extractCIDRsFromFeed(ctx, k8sClient, ingress.Namespace, "default/stale")
+		Expect(err).To(MatchError(ContainSubstring("is stale")))
+		_, err = extractCIDRsFromFeed(ctx, k8sClient, ingress.Namespace, "kube-system/stale")
+		Expect(err).To(MatchError(ContainSubstring("outside namespace")))
+	})
+
+	It("should deny all access when the only allow source is a feed that was never fetched or went stale", func() {
+		feed := &ingressnetworkpoliciesv1.CIDRFeed{
+			ObjectMeta: metav1.ObjectMeta{Name: "partners-unfetched", Namespace: "default"},
+			Spec: ingressnetworkpoliciesv1.CIDRFeedSpec{
+				URL:    "http://127.0.0.1:1/feed",
+				MaxAge: &metav1.Duration{Duration: time.Hour},
+			},
+		}
+		Expect(k8sClient.Create(ctx, feed)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, feed)).To(Succeed()) }()
+
+		ingress := newTestIngress("feed-only-app", map[string]string{AnnotationWhiteListNetworkPolicy: "feed:default/partners-unfetched"})
+		Expect(k8sClient.Create(ctx, ingress)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, ingress)).To(Succeed()) }()
+
+		recorder := record.NewFakeRecorder(10)
+		ingressKey := types.NamespacedName{Name: ingress.Name, Namespace: ingress.Namespace}
+		ingressReconciler := &IngressReconciler{Client: k8sClient, Scheme: k8sClient.Scheme(), Access: AccessConfig{Recorder: recorder}}
+
+		updated := reconcileAndGet(ingressReconciler, ingressKey)
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxWhitelist, "0.0.0.0/32,::/128"))
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationUnresolvedSources, "feed:default/partners-unfetched"))
+		Expect(recorder.Events).To(Receive(ContainSubstring(EventReasonUnresolvedSource)))
+		Expect(recorder.Events).To(Receive(ContainSubstring(EventReasonDenyAll)))
+
+		// The prefixes are used once fetched
+		feed.Status = ingressnetworkpoliciesv1.CIDRSourceStatus{
+			Prefixes:                []string{"10.81.0.0/24"},
+			LastSuccessfulFetchTime: &metav1.Time{Time: time.Now()},
+		}
+		Expect(k8sClient.Status().Update(ctx, feed)).To(Succeed())
+		updated = reconcileAndGet(ingressReconciler, ingressKey)
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxWhitelist, "10.81.0.0/24"))
+		Expect(updated.Annotations).NotTo(HaveKey(AnnotationUnresolvedSources))
+
+		// And no longer once stale
+		feed.Status.LastSuccessfulFetchTime = &metav1.Time{Time: time.Now().Add(-2 * time.Hour)}
+		Expect(k8sClient.Status().Update(ctx, feed)).To(Succeed())
+		updated = reconcileAndGet(ingressReconciler, ingressKey)
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxWhitelist, "0.0.0.0/32,::/128"))
+	})
+})
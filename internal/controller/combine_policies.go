@@ -0,0 +1,276 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"slices"
+	"strings"
+	"unicode"
+
+	v1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// errInvalidCombination is returned for malformed whitelist combinations.
+var errInvalidCombination = errors.New("invalid whitelist combination")
+
+// combination is a node of a whitelist combination expression, either an operand or an operator
+// combining the prefix sets of its left and right node.
+type combination struct {
+	// operator is one of |, & and -, or zero for operands.
+	operator byte
+	// operand is a reference of networking.k8s.io/whitelist-policy or an entry of networking.k8s.io/whitelist.
+	operand     string
+	left, right *combination
+}
+
+// operands returns the operands of the expression, each once and in order of appearance.
+func (c *combination) operands() []string {
+	if c.operator == 0 {
+		return []string{c.operand}
+	}
+	operands := c.left.operands()
+	for _, operand := range c.right.operands() {
+		if !slices.Contains(operands, operand) {
+			operands = append(operands, operand)
+		}
+	}
+	return operands
+}
+
+// evaluate combines the prefix sets of the operands.
+func (c *combination) evaluate(sets map[string][]netip.Prefix) []netip.Prefix {
+	if c.operator == 0 {
+		return sets[c.operand]
+	}
+	left, right := c.left.evaluate(sets), c.right.evaluate(sets)
+	switch c.operator {
+	case '|':
+		return slices.Concat(left, right)
+	case '&':
+		return subtractPrefixes(left, subtractPrefixes(left, right))
+	default:
+		return subtractPrefixes(left, right)
+	}
+}
+
+// combinationToken is a token of a whitelist combination expression, at its 1-based position.
+type combinationToken struct {
+	value    string
+	position int
+}
+
+// tokenizeCombination splits the expression into operands, parentheses and the operators | and &.
+// Since references often contain dashes, - is only the difference operator when it stands alone.
+func tokenizeCombination(expression string) []combinationToken {
+	var tokens []combinationToken
+	start := -1
+	flush := func(end int) {
+		if start >= 0 {
+			tokens = append(tokens, combinationToken{value: expression[start:end], position: start + 1})
+			start = -1
+		}
+	}
+
+	for i, r := range expression {
+		switch {
+		case unicode.IsSpace(r):
+			flush(i)
+		case strings.ContainsRune("|&()", r):
+			flush(i)
+			tokens = append(tokens, combinationToken{value: string(r), position: i + 1})
+		case start < 0:
+			start = i
+		}
+	}
+	flush(len(expression))
+
+	return tokens
+}
+
+// parseCombination parses a whitelist combination expression like corp & (oslo | bergen) - contractors.
+// & and - bind stronger than |, and operators of equal strength are evaluated from left to right.
+func parseCombination(expression string) (*combination, error) {
+	parser := combinationParser{tokens: tokenizeCombination(expression)}
+	if len(parser.tokens) == 0 {
+		return nil, fmt.Errorf("%w: empty expression", errInvalidCombination)
+	}
+
+	node, err := parser.union()
+	if err != nil {
+		return nil, err
+	}
+	if token, ok := parser.peek(); ok {
+		return nil, fmt.Errorf("%w: unexpected %q at position %d, expected an operator", errInvalidCombination, token.value, token.position)
+	}
+	return node, nil
+}
+
+// combinationParser is a recursive descent parser of whitelist combination expressions.
+type combinationParser struct {
+	tokens []combinationToken
+	next   int
+}
+
+// peek returns the next token without consuming it.
+func (p *combinationParser) peek() (combinationToken, bool) {
+	if p.next >= len(p.tokens) {
+		return combinationToken{}, false
+	}
+	return p.tokens[p.next], true
+}
+
+// union parses operands separated by |.
+func (p *combinationParser) union() (*combination, error) {
+	node, err := p.intersection()
+	if err != nil {
+		return nil, err
+	}
+	for token, ok := p.peek(); ok && token.value == "|"; token, ok = p.peek() {
+		p.next++
+		right, err := p.intersection()
+		if err != nil {
+			return nil, err
+		}
+		node = &combination{operator: '|', left: node, right: right}
+	}
+	return node, nil
+}
+
+// intersection parses operands separated by & and -.
+func (p *combinationParser) intersection() (*combination, error) {
+	node, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+	for token, ok := p.peek(); ok && (token.value == "&" || token.value == "-"); token, ok = p.peek() {
+		p.next++
+		right, err := p.operand()
+		if err != nil {
+			return nil, err
+		}
+		node = &combination{operator: token.value[0], left: node, right: right}
+	}
+	return node, nil
+}
+
+// operand parses an operand or a parenthesized expression.
+func (p *combinationParser) operand() (*combination, error) {
+	token, ok := p.peek()
+	if !ok {
+		return nil, fmt.Errorf("%w: expected a reference at the end of the expression", errInvalidCombination)
+	}
+	p.next++
+
+	switch token.value {
+	case "(":
+		node, err := p.union()
+		if err != nil {
+			return nil, err
+		}
+		if closing, ok := p.peek(); !ok || closing.value != ")" {
+			return nil, fmt.Errorf("%w: missing closing parenthesis for position %d", errInvalidCombination, token.position)
+		}
+		p.next++
+		return node, nil
+	case ")", "|", "&", "-":
+		return nil, fmt.Errorf("%w: unexpected %q at position %d, expected a reference", errInvalidCombination, token.value, token.position)
+	}
+	return &combination{operand: token.value}, nil
+}
+
+// whitelistCombination returns the combination of the whitelist references of the Ingress,
+// or nil when they are unioned. With intersection, every reference is intersected. Operands of
+// expressions must be listed in the references or entries, and every required reference must be used.
+// The references of namespace defaults and selectors are not required, and are ignored unless used.
+func whitelistCombination(ingress *v1.Ingress, references []string, entries []string, required []string) (*combination, error) {
+	mode := strings.TrimSpace(ingress.GetAnnotations()[AnnotationWhitelistCombination])
+
+	switch mode {
+	case "", CombinationUnion:
+		return nil, nil
+	case CombinationIntersection:
+		var node *combination
+		for _, reference := range references {
+			operand := &combination{operand: reference}
+			if node == nil {
+				node = operand
+				continue
+			}
+			node = &combination{operator: '&', left: node, right: operand}
+		}
+		return node, nil
+	}
+
+	node, err := parseCombination(mode)
+	if err != nil {
+		return nil, err
+	}
+	operands := node.operands()
+	for _, operand := range operands {
+		if !slices.Contains(references, operand) && !slices.Contains(entries, operand) {
+			return nil, fmt.Errorf("%w: %q is neither a reference of %s nor an entry of %s", errInvalidCombination, operand, AnnotationWhiteListNetworkPolicy, AnnotationWhitelist)
+		}
+	}
+	for _, reference := range required {
+		if !slices.Contains(operands, reference) {
+			return nil, fmt.Errorf("%w: the reference %q is not used in the expression", errInvalidCombination, reference)
+		}
+	}
+	return node, nil
+}
+
+// ValidateIngressCombination checks the syntax of the whitelist combination of the Ingress.
+// The operands are only known once namespace defaults and selectors are resolved, and are checked by the reconcilers.
+func ValidateIngressCombination(ingress *v1.Ingress) error {
+	mode := strings.TrimSpace(ingress.GetAnnotations()[AnnotationWhitelistCombination])
+	if mode == "" || mode == CombinationUnion || mode == CombinationIntersection {
+		return nil
+	}
+	_, err := parseCombination(mode)
+	return err
+}
+
+// createCombinedCidrList resolves each operand of the combination to a prefix set and combines them.
+// Entries not used in the combination are added to the result, like they are added to the references otherwise.
+// Operands that can't be resolved are refused, since an empty set for f.ex contractors in corp - contractors
+// would allow more than requested.
+func createCombinedCidrList(ctx context.Context, r client.Reader, config AccessConfig, ingress *v1.Ingress, node *combination, references []string, entries []string, ports []intstr.IntOrString) (cidrList, error) {
+	var list cidrList
+	var unresolved []string
+	sets := map[string][]netip.Prefix{}
+
+	operands := node.operands()
+	for _, operand := range operands {
+		var resolved cidrList
+		if slices.Contains(references, operand) {
+			resolved = createCidrList(ctx, r, config, ingress, ingress.Namespace, []string{operand}, nil, ports, false)
+		} else {
+			resolved = createCidrList(ctx, r, config, ingress, ingress.Namespace, nil, []string{operand}, ports, false)
+		}
+		sets[operand] = parsePrefixes(resolved.cidrs)
+		list.refreshBy(resolved.refreshAt)
+		list.expired = append(list.expired, resolved.expired...)
+		list.unauthorized = append(list.unauthorized, resolved.unauthorized...)
+		list.unresolved = append(list.unresolved, resolved.unresolved...)
+		if len(resolved.unresolved) > 0 || len(resolved.unauthorized) > 0 {
+			unresolved = append(unresolved, operand)
+		}
+	}
+	if len(unresolved) > 0 {
+		return list, fmt.Errorf("%w: unable to resolve %s", errInvalidCombination, strings.Join(unresolved, ", "))
+	}
+
+	for _, prefix := range node.evaluate(sets) {
+		list.cidrs = append(list.cidrs, prefix.String())
+	}
+
+	remaining := slices.DeleteFunc(slices.Clone(entries), func(entry string) bool { return slices.Contains(operands, entry) })
+	list.addEntries(ctx, r, config, ingress, ingress.Namespace, "", remaining)
+	list.cidrs = sortSlice(list.cidrs)
+
+	return list, nil
+}
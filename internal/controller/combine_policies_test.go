@@ -0,0 +1,201 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	ingressnetworkpoliciesv1 "github.com/vitistack/ingressnetworkpolicy-operator/api/v1"
+)
+
+var _ = Describe("Whitelist combinations", func() {
+	ctx := context.Background()
+
+	var policies []*networkingv1.NetworkPolicy
+
+	BeforeEach(func() {
+		ensureNamespace(ctx, "default")
+		ensureNamespace(ctx, DefaultNamespace)
+
+		policies = nil
+		for name, cidrs := range map[string][]string{
+			"corp":        {"10.0.0.0/8"},
+			"oslo":        {"10.1.0.0/16"},
+			"bergen":      {"10.2.0.0/16", "192.0.2.0/24"},
+			"contractors": {"10.1.0.0/17"},
+		} {
+			policy := &networkingv1.NetworkPolicy{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: DefaultNamespace}}
+			for _, cidr := range cidrs {
+				policy.Spec.Ingress = append(policy.Spec.Ingress, networkingv1.NetworkPolicyIngressRule{
+					From: []networkingv1.NetworkPolicyPeer{{IPBlock: &networkingv1.IPBlock{CIDR: cidr}}},
+				})
+			}
+			Expect(k8sClient.Create(ctx, policy)).To(Succeed())
+			policies = append(policies, policy)
+		}
+	})
+
+	AfterEach(func() {
+		for _, policy := range policies {
+			Expect(k8sClient.Delete(ctx, policy)).To(Succeed())
+		}
+	})
+
+	reconcileIngress := func(recorder record.EventRecorder, annotations map[string]string) *networkingv1.Ingress {
+		ingress := newTestIngress("combination-app", annotations)
+		Expect(k8sClient.Create(ctx, ingress)).To(Succeed())
+		DeferCleanup(func() { Expect(k8sClient.Delete(ctx, ingress)).To(Succeed()) })
+
+		reconciler := &IngressReconciler{Client: k8sClient, Scheme: k8sClient.Scheme(), Access: AccessConfig{Recorder: recorder}}
+		return reconcileAndGet(reconciler, client.ObjectKeyFromObject(ingress))
+	}
+
+	It("should report where expressions are malformed", func() {
+		for expression, message := range map[string]string{
+			"":                   "empty expression",
+			"corp &":             "expected a reference at the end of the expression",
+			"corp & | oslo":      `unexpected "|" at position 8, expected a reference`,
+			"corp & (oslo":       "missing closing parenthesis for position 8",
+			"corp oslo":          `unexpected "oslo" at position 6, expected an operator`,
+			"corp & oslo)":       `unexpected ")" at position 12, expected an operator`,
+			"- corp":             `unexpected "-" at position 1, expected a reference`,
+			"corp-net - oslo)(":  `unexpected ")" at position 16, expected an operator`,
+			"(corp | oslo) bad&": `unexpected "bad" at position 15, expected an operator`,
+		} {
+			_, err := parseCombination(expression)
+			Expect(err).To(MatchError(errInvalidCombination), expression)
+			Expect(err).To(MatchError(ContainSubstring(message)), expression)
+		}
+
+		node, err := parseCombination("corp & (oslo | bergen) - contractors")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(node.operands()).To(Equal([]string{"corp", "oslo", "bergen", "contractors"}))
+
+		ingress := newTestIngress("combination-app", map[string]string{AnnotationWhitelistCombination: "corp &"})
+		Expect(ValidateIngressCombination(ingress)).To(MatchError(errInvalidCombination))
+		ingress.Annotations[AnnotationWhitelistCombination] = CombinationIntersection
+		Expect(ValidateIngressCombination(ingress)).To(Succeed())
+	})
+
+	It("should evaluate expressions over the prefix sets of the references", func() {
+		updated := reconcileIngress(nil, map[string]string{
+			AnnotationWhiteListNetworkPolicy: "corp,oslo,bergen,contractors",
+			AnnotationWhitelist:              "203.0.113.7/32",
+			AnnotationWhitelistCombination:   "corp & (oslo | bergen) - contractors",
+		})
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxWhitelist, "10.1.128.0/17,10.2.0.0/16,203.0.113.7/32"))
+	})
+
+	It("should intersect every reference", func() {
+		updated := reconcileIngress(nil, map[string]string{
+			AnnotationWhiteListNetworkPolicy: "corp,bergen",
+			AnnotationWhitelistCombination:   CombinationIntersection,
+		})
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxWhitelist, "10.2.0.0/16"))
+	})
+
+	It("should keep the rendered allowlist and render the denylist when the combination is invalid", func() {
+		recorder := record.NewFakeRecorder(10)
+		reconciler := &IngressReconciler{Client: k8sClient, Scheme: k8sClient.Scheme(), Access: AccessConfig{Recorder: recorder}}
+		reconcileUpdate := func(ingress *networkingv1.Ingress) *networkingv1.Ingress {
+			Expect(k8sClient.Update(ctx, ingress)).To(Succeed())
+			return reconcileAndGet(reconciler, client.ObjectKeyFromObject(ingress))
+		}
+
+		updated := reconcileIngress(recorder, map[string]string{
+			AnnotationWhiteListNetworkPolicy: "corp,oslo",
+			AnnotationWhitelistCombination:   "corp & oslo",
+		})
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxWhitelist, "10.1.0.0/16"))
+
+		updated.Annotations[AnnotationWhitelistCombination] = "corp & partners"
+		updated.Annotations[AnnotationDenylist] = "10.1.0.7/32"
+		updated = reconcileUpdate(updated)
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxWhitelist, "10.1.0.0/16"))
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxDenylist, "10.1.0.7/32"))
+		Eventually(recorder.Events).Should(Receive(ContainSubstring(`"partners" is neither a reference`)))
+
+		// A reference that can't be resolved would allow contractors instead of subtracting them
+		updated.Annotations[AnnotationWhiteListNetworkPolicy] = "corp,missing"
+		updated.Annotations[AnnotationWhitelistCombination] = "corp - missing"
+		updated = reconcileUpdate(updated)
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxWhitelist, "10.1.0.0/16"))
+		Eventually(recorder.Events).Should(Receive(ContainSubstring("unable to resolve missing")))
+	})
+
+	It("should deny all access and requeue while the combination resolves to no CIDRs", func() {
+		never := &ingressnetworkpoliciesv1.AccessSchedule{
+			ObjectMeta: metav1.ObjectMeta{Name: "combination-leap-day", Namespace: DefaultNamespace},
+			Spec:       ingressnetworkpoliciesv1.AccessScheduleSpec{Cron: "0 0 29 2 *", Duration: metav1.Duration{Duration: time.Minute}},
+		}
+		Expect(k8sClient.Create(ctx, never)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, never)).To(Succeed()) }()
+
+		ingress := newTestIngress("combination-app", map[string]string{
+			AnnotationWhiteListNetworkPolicy: "corp,oslo~combination-leap-day",
+			AnnotationWhitelistCombination:   "corp & oslo~combination-leap-day",
+		})
+		Expect(k8sClient.Create(ctx, ingress)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, ingress)).To(Succeed()) }()
+
+		reconciler := &IngressReconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+		result, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(ingress)})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+
+		updated := &networkingv1.Ingress{}
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(ingress), updated)).To(Succeed())
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxWhitelist, "0.0.0.0/32,::/128"))
+	})
+
+	It("should only require the references of the Ingress to be used", func() {
+		namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name:        "combination-defaults",
+			Annotations: map[string]string{AnnotationDefaultWhiteListPolicy: "bergen"},
+		}}
+		Expect(k8sClient.Create(ctx, namespace)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, namespace)).To(Succeed()) }()
+
+		ingress := newTestIngress("combination-app", map[string]string{
+			AnnotationWhiteListNetworkPolicy: "corp,contractors",
+			AnnotationWhitelistCombination:   "corp - contractors",
+		})
+		ingress.Namespace = namespace.Name
+		Expect(k8sClient.Create(ctx, ingress)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, ingress)).To(Succeed()) }()
+
+		reconciler := &IngressReconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+		_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(ingress)})
+		Expect(err).NotTo(HaveOccurred())
+
+		updated := &networkingv1.Ingress{}
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(ingress), updated)).To(Succeed())
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationAppliedDefaults, ContainSubstring("bergen")))
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxWhitelist, ContainSubstring("10.0.0.0/16")))
+		Expect(updated.Annotations[AnnotationNginxWhitelist]).NotTo(ContainSubstring("192.0.2.0/24"))
+	})
+})
@@ -1,11 +1,105 @@
 package controller
 
 const (
-	DefaultNamespace                 = "network-policies"
-	AnnotationNginxWhitelist         = "nginx.ingress.kubernetes.io/whitelist-source-range"
-	AnnotationNginxDenylist          = "nginx.ingress.kubernetes.io/denylist-source-range"
-	AnnotationWhiteListNetworkPolicy = "networking.k8s.io/whitelist-policy"
-	AnnotationDenyListNetworkPolicy  = "networking.k8s.io/denylist-policy"
-	AnnotationWhitelist              = "networking.k8s.io/whitelist"
-	AnnotationDenylist               = "networking.k8s.io/denylist"
+	DefaultNamespace                  = "network-policies"
+	AnnotationNginxWhitelist          = "nginx.ingress.kubernetes.io/whitelist-source-range"
+	AnnotationNginxDenylist           = "nginx.ingress.kubernetes.io/denylist-source-range"
+	AnnotationNginxCanary             = "nginx.ingress.kubernetes.io/canary"
+	AnnotationWhiteListNetworkPolicy  = "networking.k8s.io/whitelist-policy"
+	AnnotationDenyListNetworkPolicy   = "networking.k8s.io/denylist-policy"
+	AnnotationWhiteListPolicySelector = "networking.k8s.io/whitelist-policy-selector"
+	AnnotationDenyListPolicySelector  = "networking.k8s.io/denylist-policy-selector"
+	AnnotationWhitelist               = "networking.k8s.io/whitelist"
+	AnnotationDenylist                = "networking.k8s.io/denylist"
+	AnnotationPolicyPorts             = "networking.k8s.io/policy-ports"
+	AnnotationInheritBackendPolicies  = "networking.k8s.io/inherit-backend-policies"
+	AnnotationDefaultWhiteListPolicy  = "networking.k8s.io/default-whitelist-policy"
+	AnnotationDefaultDenyListPolicy   = "networking.k8s.io/default-denylist-policy"
+	AnnotationNamespaceDefaults       = "networking.k8s.io/namespace-defaults"
+	AnnotationLockdown                = "networking.k8s.io/lockdown"
+	AnnotationWhitelistCombination    = "networking.k8s.io/whitelist-combination"
+	AnnotationIngressClass            = "kubernetes.io/ingress.class"
+	AnnotationKongPlugins             = "konghq.com/plugins"
+	AnnotationApisixPluginConfig      = "k8s.apisix.apache.org/plugin-config-name"
+	AnnotationRenderer                = "ingressnetworkpolicies.vitistack.io/renderer"
+	AnnotationNginxAllowlistKey       = "ingressnetworkpolicies.vitistack.io/nginx-allowlist-annotation"
+	AnnotationNginxDenylistKey        = "ingressnetworkpolicies.vitistack.io/nginx-denylist-annotation"
+	AnnotationNginxKeyMigration       = "ingressnetworkpolicies.vitistack.io/nginx-annotation-migration"
+	AnnotationManagedAllowlistKeys    = "ingressnetworkpolicies.vitistack.io/managed-allowlist-annotations"
+	AnnotationManagedDenylistKeys     = "ingressnetworkpolicies.vitistack.io/managed-denylist-annotations"
+	AnnotationControllerPorts         = "ingressnetworkpolicies.vitistack.io/controller-ports"
+	AnnotationInheritedPolicies       = "ingressnetworkpolicies.vitistack.io/inherited-policies"
+	AnnotationSelectedWhitelist       = "ingressnetworkpolicies.vitistack.io/selected-whitelist-policies"
+	AnnotationSelectedDenylist        = "ingressnetworkpolicies.vitistack.io/selected-denylist-policies"
+	AnnotationInvalidSelector         = "ingressnetworkpolicies.vitistack.io/invalid-selector"
+	AnnotationExpiredEntries          = "ingressnetworkpolicies.vitistack.io/expired-entries"
+	AnnotationAccessFindings          = "ingressnetworkpolicies.vitistack.io/access-findings"
+	AnnotationUnauthorizedPolicies    = "ingressnetworkpolicies.vitistack.io/unauthorized-policies"
+	AnnotationUnresolvedSources       = "ingressnetworkpolicies.vitistack.io/unresolved-sources"
+	AnnotationAppliedDefaults         = "ingressnetworkpolicies.vitistack.io/applied-defaults"
+	AnnotationEmergencyBlocks         = "ingressnetworkpolicies.vitistack.io/emergency-blocks"
+	AnnotationAppliedLockdown         = "ingressnetworkpolicies.vitistack.io/applied-lockdown"
+	AnnotationSavedAllowlist          = "ingressnetworkpolicies.vitistack.io/saved-allowlist"
+	AnnotationSavedDenylist           = "ingressnetworkpolicies.vitistack.io/saved-denylist"
+	AnnotationCanaryOf                = "ingressnetworkpolicies.vitistack.io/canary-of"
+	AnnotationDenyAll                 = "ingressnetworkpolicies.vitistack.io/deny-all"
+	AnnotationAllowedNamespaces       = "ingressnetworkpolicies.vitistack.io/allowed-namespaces"
+	AnnotationAllowedSelector         = "ingressnetworkpolicies.vitistack.io/allowed-namespace-selector"
+	AnnotationSubtractDenylist        = "ingressnetworkpolicies.vitistack.io/subtract-denylist"
+	LabelCIDRSource                   = "ingressnetworkpolicies.vitistack.io/cidr-source"
+)
+
+const (
+	RendererNginx                    = "nginx"
+	RendererKong                     = "kong"
+	RendererApisix                   = "apisix"
+	IngressControllerKong            = "ingress-controllers.konghq.com/kong"
+	IngressControllerApisix          = "apisix.apache.org/apisix-ingress-controller"
+	SourceConfigMap                  = "configmap"
+	SourceSecret                     = "secret"
+	SourceFeed                       = "feed"
+	SourceNetBox                     = "netbox"
+	SourceCIDRSet                    = "cidrset"
+	SourceNodeExternalIP             = "node-external-ip"
+	SourceNodeInternalIP             = "node-internal-ip"
+	SourceNodePodCIDR                = "node-pod-cidr"
+	SourceServiceLoadBalancer        = "service-lb"
+	SourceCalicoGlobalNetworkSet     = "calico-gns"
+	SourceCalicoNetworkSet           = "calico-ns"
+	SourceCiliumCIDRGroup            = "cilium-cidrgroup"
+	SourceAdminNetworkPolicy         = "anp"
+	SourceBaselineAdminNetworkPolicy = "banp"
+	EntryPrefixDNS                   = "dns:"
+	EntryPrefixGeo                   = "geo:"
+	ScheduleSeparator                = "~"
+	PolicyPortsController            = "controller"
+	DefaultControllerPorts           = "80,443"
+	EventReasonAccessExpired         = "AccessExpired"
+	EventReasonGuardrailViolation    = "GuardrailViolation"
+	EventReasonUnauthorizedPolicy    = "UnauthorizedPolicy"
+	EventReasonUnresolvedSource      = "UnresolvedSource"
+	EventReasonOptOutRefused         = "DefaultsOptOutRefused"
+	EventReasonEmergencyBlock        = "EmergencyBlock"
+	EventReasonEmergencyBlockAdded   = "EmergencyBlockAdded"
+	EventReasonEmergencyBlockRemoved = "EmergencyBlockRemoved"
+	EventReasonEmergencyBlockExpired = "EmergencyBlockExpired"
+	EventReasonLockdownApplied       = "LockdownApplied"
+	EventReasonLockdownLifted        = "LockdownLifted"
+	EventReasonLockdownFailed        = "LockdownFailed"
+	EventReasonInvalidCombination    = "InvalidCombination"
+	EventReasonCanaryAccessMismatch  = "CanaryAccessMismatch"
+	EventReasonDenyAll               = "DenyAll"
+	EventReasonInvalidSelector       = "InvalidSelector"
+	EventReasonNotOwned              = "NotOwned"
+	NamespaceDefaultsExtend          = "extend"
+	NamespaceDefaultsReplace         = "replace"
+	NamespaceDefaultsOptOut          = "opt-out"
+	CombinationUnion                 = "union"
+	CombinationIntersection          = "intersection"
+	LabelACMESolver                  = "acme.cert-manager.io/http01-solver"
+	ACMEChallengePathPrefix          = "/.well-known/acme-challenge/"
+	ACMEGroup                        = "acme.cert-manager.io"
+	ACMEChallengeKind                = "Challenge"
+	ACMEPolicyExempt                 = "exempt"
+	ACMEPolicyCARanges               = "ca-ranges"
 )
@@ -2,25 +2,132 @@ package controller
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
 
 	v1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 )
 
+// errAdminNetworkPolicySource is reported for references to AdminNetworkPolicies and BaselineAdminNetworkPolicies.
+var errAdminNetworkPolicySource = errors.New("AdminNetworkPolicy ingress rules have no networks peers to extract CIDRs from")
+
+// errInvalidExpiry is logged for entries of objects with an invalid expiry, instead of the error quoting the entry.
+var errInvalidExpiry = errors.New("invalid expiry, expected f.ex 2026-11-01T00:00Z")
+
 type Getter interface {
 	Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error
 }
 
-func createCidrList(ctx context.Context, r Getter, ingress v1.Ingress, policyList []string, customList []string) []string {
+// cidrList is the result of resolving the policies and entries of an Ingress.
+type cidrList struct {
+	// cidrs is the list of CIDRs, sorted once complete.
+	cidrs []string
+	// refreshAt is the earliest time the list may change by itself, f.ex when a DNS answer or an entry expires.
+	refreshAt time.Time
+	// expired lists the entries dropped since they expired.
+	expired []string
+	// unauthorized lists the NetworkPolicies and sources dropped since the Ingress namespace may not reference them.
+	unauthorized []string
+	// unresolved lists the references and entries dropped since they could not be resolved,
+	// f.ex missing NetworkPolicies or stale feeds.
+	unresolved []string
+	// deny marks denylists, which keep references and entries whose schedule can't be evaluated.
+	deny bool
+}
+
+// refreshBy records that the list may change at the given time.
+func (l *cidrList) refreshBy(t time.Time) {
+	l.refreshAt = earliest(l.refreshAt, t)
+}
+
+// createCidrList resolves the policies and custom entries to a sorted list of CIDRs for the Ingress, for a denylist when deny is set.
+// Sources are resolved as the namespace, the Ingress namespace unless they belong to a cluster-wide policy.
+func createCidrList(ctx context.Context, r client.Reader, config AccessConfig, ingress *v1.Ingress, namespace string, policyList []string, customList []string, ports []intstr.IntOrString, deny bool) cidrList {
 	log := logf.FromContext(ctx)
 
-	var cidrs []string
+	list := cidrList{deny: deny}
 
 	// Get each NetworkPolicy and extract CIDRs
 
 	for _, networkPolicy := range policyList {
 
+		// Skip references limited to a schedule outside its windows
+		networkPolicy, schedule := splitScheduleReference(networkPolicy)
+		if schedule != "" && !list.scheduleOpen(ctx, r, ingress, namespace, schedule) {
+			continue
+		}
+
+		// Resolve typed references to other sources
+		if kind, path := splitSourceReference(networkPolicy); kind != "" {
+			switch kind {
+			case SourceConfigMap, SourceSecret:
+				entries, err := extractCIDRsFromObjectKey(ctx, r, namespace, kind, path)
+				if err != nil {
+					list.dropReference(ctx, ingress, networkPolicy, err)
+					continue
+				}
+				list.addEntries(ctx, r, config, ingress, namespace, networkPolicy, entries)
+			case SourceCIDRSet:
+				entries, err := extractCIDRsFromCIDRSet(ctx, r, namespace, path)
+				if err != nil {
+					list.dropReference(ctx, ingress, networkPolicy, err)
+					continue
+				}
+				list.addEntries(ctx, r, config, ingress, namespace, networkPolicy, entries)
+			case SourceNodeExternalIP, SourceNodeInternalIP, SourceNodePodCIDR, SourceServiceLoadBalancer:
+				var entries []string
+				var err error
+				if kind == SourceServiceLoadBalancer {
+					entries, err = extractCIDRsFromServices(ctx, r, namespace, path)
+				} else {
+					entries, err = extractCIDRsFromNodes(ctx, r, kind, path)
+				}
+				if err != nil {
+					list.dropReference(ctx, ingress, networkPolicy, err)
+					continue
+				}
+				list.cidrs = append(list.cidrs, entries...)
+			case SourceFeed, SourceNetBox:
+				extract := extractCIDRsFromFeed
+				if kind == SourceNetBox {
+					extract = extractCIDRsFromNetBox
+				}
+				entries, err := extract(ctx, r, namespace, path)
+				if err != nil {
+					list.dropReference(ctx, ingress, networkPolicy, err)
+					continue
+				}
+				list.cidrs = append(list.cidrs, entries...)
+			case SourceCalicoGlobalNetworkSet, SourceCalicoNetworkSet, SourceCiliumCIDRGroup:
+				source, _ := findNetworkSetSource(kind)
+				entries, err := extractCIDRsFromNetworkSet(ctx, r, namespace, source, path)
+				if err != nil {
+					list.dropReference(ctx, ingress, networkPolicy, err)
+					continue
+				}
+				for _, cidr := range entries {
+					if prefix, ok := normalizePrefix(cidr); ok {
+						list.cidrs = append(list.cidrs, prefix)
+					}
+				}
+			case SourceAdminNetworkPolicy, SourceBaselineAdminNetworkPolicy:
+				// The ingress peers of policy.networking.k8s.io/v1alpha1 only select namespaces and pods,
+				// networks peers only exist on egress rules and describe destinations, not clients.
+				log.Error(errAdminNetworkPolicySource, "unable to resolve source for Ingress", "Ingress.Name", ingress.Name, "ExpectedSource", networkPolicy)
+				list.unresolved = append(list.unresolved, networkPolicy)
+			default:
+				log.Info("unknown source kind for Ingress", "Ingress.Name", ingress.Name, "ExpectedSource", networkPolicy)
+				list.unresolved = append(list.unresolved, networkPolicy)
+			}
+			continue
+		}
+
 		processNetworkPolicy := v1.NetworkPolicy{}
 
 		err := r.Get(ctx, client.ObjectKey{
@@ -30,22 +137,193 @@ func createCidrList(ctx context.Context, r Getter, ingress v1.Ingress, policyLis
 
 		if err != nil {
 			log.Error(err, "unable to fetch NetworkPolicy for Ingress", "Ingress.Name", ingress.Name, "ExpectedPolicy", networkPolicy)
+			list.unresolved = append(list.unresolved, networkPolicy)
+			continue
+		}
+
+		// Shared NetworkPolicies may restrict the namespaces referencing them
+		authorized, err := sourceAuthorized(ctx, r, &processNetworkPolicy, namespace)
+		if err != nil {
+			log.Error(err, "unable to authorize NetworkPolicy for Ingress", "Ingress.Name", ingress.Name, "ExpectedPolicy", networkPolicy)
+			list.unresolved = append(list.unresolved, networkPolicy)
+			continue
+		}
+		if !authorized {
+			log.Info("NetworkPolicy is not authorized for the Ingress namespace", "Ingress.Name", ingress.Name, "Ingress.Namespace", ingress.Namespace, "ExpectedPolicy", networkPolicy)
+			list.unauthorized = append(list.unauthorized, networkPolicy)
 			continue
 		}
 
 		// Extract CIDRs from NetworkPolicy and append to list
-		cidrs = append(cidrs, extractCIDRsFromNetworkPolicy(&processNetworkPolicy, cidrs)...)
+		list.cidrs = append(list.cidrs, extractCIDRsFromNetworkPolicy(&processNetworkPolicy, list.cidrs, ports)...)
 	}
 
-	// Append valid CIDRs from customList
-	for _, cidr := range customList {
-		if checkValidCIDR(cidr) {
-			cidrs = append(cidrs, cidr)
+	// Append valid CIDRs and resolved hosts from customList
+	list.addEntries(ctx, r, config, ingress, namespace, "", customList)
+
+	// Remove duplicates and sort
+	list.cidrs = sortSlice(list.cidrs)
+
+	return list
+}
+
+// dropReference records a typed reference that can't be resolved, or that the namespace of the Ingress may not reference.
+func (l *cidrList) dropReference(ctx context.Context, ingress *v1.Ingress, reference string, err error) {
+	log := logf.FromContext(ctx)
+
+	if errors.Is(err, errUnauthorizedSource) {
+		log.Info("Source is not authorized for the Ingress namespace", "Ingress.Name", ingress.Name, "Ingress.Namespace", ingress.Namespace, "ExpectedSource", reference)
+		l.unauthorized = append(l.unauthorized, reference)
+		return
+	}
+
+	log.Error(err, "unable to resolve source for Ingress", "Ingress.Name", ingress.Name, "ExpectedSource", reference)
+	l.unresolved = append(l.unresolved, reference)
+}
+
+// addEntries adds the valid CIDRs of the entries, with dns: entries resolved to host prefixes
+// and geo: entries to the prefixes of the country. Entries with an expiry, f.ex 203.0.113.7/32@2026-11-01T00:00Z,
+// are dropped once expired and schedule a refresh at their expiry until then. Entries limited to an AccessSchedule,
+// f.ex 203.0.113.0/24~maintenance, are only added while a window of the schedule is open.
+//
+// The source is the reference of the object holding the entries, f.ex a Secret, and empty for entries of annotations.
+// Since the values of objects may be confidential, their dropped entries are only counted and recorded by source.
+func (l *cidrList) addEntries(ctx context.Context, r Getter, config AccessConfig, ingress *v1.Ingress, namespace string, source string, entries []string) {
+	log := logf.FromContext(ctx)
+
+	now := time.Now()
+
+	var unresolved, expired int
+	// describe returns the entry to log, or its source when the value may be confidential
+	describe := func(entry string) string {
+		if source != "" {
+			return source
+		}
+		return entry
+	}
+	dropUnresolved := func(entry string) {
+		if source != "" {
+			unresolved++
+			return
 		}
+		l.unresolved = append(l.unresolved, entry)
 	}
 
-	// Remove duplicates and sort
-	compactSortedCIDRs := sortSlice(cidrs)
+	for _, entry := range entries {
+		value, expires, err := parseEntryExpiry(entry)
+		if err != nil {
+			if source != "" {
+				err = errInvalidExpiry
+			}
+			log.Error(err, "invalid entry for Ingress", "Ingress.Name", ingress.Name, "Entry", describe(entry))
+			dropUnresolved(entry)
+			continue
+		}
+		if !expires.IsZero() {
+			if !now.Before(expires) {
+				if source != "" {
+					expired++
+				} else {
+					l.expired = append(l.expired, entry)
+				}
+				continue
+			}
+			l.refreshBy(expires)
+		}
+		entry, schedule := splitScheduleReference(value)
+		if schedule != "" && !l.scheduleOpen(ctx, r, ingress, namespace, schedule) {
+			continue
+		}
+
+		if country, isCountry := strings.CutPrefix(entry, EntryPrefixGeo); isCountry {
+			if config.GeoIP == nil {
+				log.Info("GeoIP is disabled, skipping entry for Ingress", "Ingress.Name", ingress.Name, "Entry", describe(entry))
+				dropUnresolved(entry)
+				continue
+			}
+			// Denying more than a large country is safer than skipping it, while allowlists only get countries as they are
+			lookup := config.GeoIP.Lookup
+			if l.deny {
+				lookup = config.GeoIP.LookupCovering
+			}
+			prefixes, err := lookup(country)
+			if err != nil {
+				log.Error(err, "unable to resolve country for Ingress", "Ingress.Name", ingress.Name, "Entry", describe(entry))
+				dropUnresolved(entry)
+				continue
+			}
+			l.cidrs = append(l.cidrs, prefixes...)
+			continue
+		}
 
-	return compactSortedCIDRs
+		host, isHost := strings.CutPrefix(entry, EntryPrefixDNS)
+		if !isHost {
+			if checkValidCIDR(entry) {
+				l.cidrs = append(l.cidrs, entry)
+			} else {
+				dropUnresolved(entry)
+			}
+			continue
+		}
+
+		if config.DNS == nil {
+			log.Info("DNS resolution is disabled, skipping entry for Ingress", "Ingress.Name", ingress.Name, "Entry", describe(entry))
+			dropUnresolved(entry)
+			continue
+		}
+
+		prefixes, refreshAt, err := config.DNS.Lookup(ctx, strings.TrimSpace(host))
+		if err != nil {
+			log.Error(err, "unable to resolve host for Ingress", "Ingress.Name", ingress.Name, "Entry", describe(entry), "PreviousAnswer", prefixes)
+			if len(prefixes) == 0 {
+				dropUnresolved(entry)
+			}
+		}
+		l.cidrs = append(l.cidrs, prefixes...)
+		l.refreshBy(refreshAt)
+	}
+
+	if unresolved > 0 {
+		l.unresolved = append(l.unresolved, fmt.Sprintf("%s (%d invalid)", source, unresolved))
+	}
+	if expired > 0 {
+		l.expired = append(l.expired, fmt.Sprintf("%s (%d expired)", source, expired))
+	}
+}
+
+// entryExpiryLayouts are the accepted layouts of entry expiries, the first is used when formatting.
+var entryExpiryLayouts = []string{time.RFC3339, "2006-01-02T15:04Z07:00", "2006-01-02"}
+
+// parseEntryExpiry splits an entry like 203.0.113.7/32@2026-11-01T00:00Z into its value and expiry.
+// Entries without an expiry have a zero expiry.
+func parseEntryExpiry(entry string) (string, time.Time, error) {
+	value, expiry, found := strings.Cut(entry, "@")
+	if !found {
+		return entry, time.Time{}, nil
+	}
+
+	expiry = strings.TrimSpace(expiry)
+	for _, layout := range entryExpiryLayouts {
+		if expires, err := time.Parse(layout, expiry); err == nil {
+			return strings.TrimSpace(value), expires, nil
+		}
+	}
+
+	return "", time.Time{}, fmt.Errorf("invalid expiry %q, expected f.ex 2026-11-01T00:00Z", expiry)
+}
+
+// formatEntryExpiry appends the expiry to the entry, if any.
+func formatEntryExpiry(entry string, expires *metav1.Time) string {
+	if expires == nil {
+		return entry
+	}
+	return entry + "@" + expires.UTC().Format(entryExpiryLayouts[0])
+}
+
+// earliest returns the earlier of the times, ignoring zero times.
+func earliest(a time.Time, b time.Time) time.Time {
+	if a.IsZero() || (!b.IsZero() && b.Before(a)) {
+		return b
+	}
+	return a
 }
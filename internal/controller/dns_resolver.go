@@ -0,0 +1,196 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// HostResolver resolves the A and AAAA records of a host.
+// It returns the addresses and the lowest TTL of the records.
+type HostResolver interface {
+	Resolve(ctx context.Context, host string) ([]net.IP, time.Duration, error)
+}
+
+// DNSServerResolver resolves hosts by querying DNS servers directly,
+// since the resolver of the standard library does not expose the TTL of the records.
+type DNSServerResolver struct {
+	// Servers are the host:port addresses of the DNS servers.
+	// The servers of /etc/resolv.conf are used when empty.
+	Servers []string
+}
+
+// Resolve queries the A and AAAA records of the host.
+// It fails when either query fails, since the addresses of the other family alone are a partial answer.
+func (d *DNSServerResolver) Resolve(ctx context.Context, host string) ([]net.IP, time.Duration, error) {
+	servers := d.Servers
+	if len(servers) == 0 {
+		config, err := dns.ClientConfigFromFile("/etc/resolv.conf")
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, server := range config.Servers {
+			servers = append(servers, net.JoinHostPort(server, config.Port))
+		}
+	}
+
+	var addresses []net.IP
+	var lastErr error
+	ttl := uint32(math.MaxUint32)
+
+	for _, recordType := range []uint16{dns.TypeA, dns.TypeAAAA} {
+		query := new(dns.Msg)
+		query.SetQuestion(dns.Fqdn(host), recordType)
+
+		response, err := exchangeDNS(ctx, query, servers)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if response.Rcode != dns.RcodeSuccess {
+			lastErr = fmt.Errorf("lookup %s %s: %s", host, dns.TypeToString[recordType], dns.RcodeToString[response.Rcode])
+			continue
+		}
+
+		// The answer holds the CNAME chain too, only the addresses are used
+		for _, answer := range response.Answer {
+			switch record := answer.(type) {
+			case *dns.A:
+				addresses = append(addresses, record.A)
+			case *dns.AAAA:
+				addresses = append(addresses, record.AAAA)
+			default:
+				continue
+			}
+			ttl = min(ttl, answer.Header().Ttl)
+		}
+	}
+
+	if lastErr != nil {
+		return nil, 0, lastErr
+	}
+	if len(addresses) == 0 {
+		return nil, 0, fmt.Errorf("lookup %s: no A or AAAA records", host)
+	}
+
+	return addresses, time.Duration(ttl) * time.Second, nil
+}
+
+// exchangeDNS sends the query to each server in turn until one answers,
+// retrying over TCP when the UDP answer is truncated.
+func exchangeDNS(ctx context.Context, query *dns.Msg, servers []string) (*dns.Msg, error) {
+	lastErr := fmt.Errorf("no DNS servers configured")
+
+	for _, server := range servers {
+		response, _, err := (&dns.Client{}).ExchangeContext(ctx, query, server)
+		if err == nil && response.Truncated {
+			response, _, err = (&dns.Client{Net: "tcp"}).ExchangeContext(ctx, query, server)
+		}
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		return response, nil
+	}
+
+	return nil, lastErr
+}
+
+// dnsCacheRetention is how long an expired answer is kept without being looked up.
+// Hosts still referenced are looked up again when their answer expires, so only
+// the answers of hosts no longer referenced are evicted.
+const dnsCacheRetention = time.Hour
+
+// DNSCache keeps the resolved prefixes of hosts until their TTL expires.
+// When a host can't be resolved again, the previous answer is kept.
+type DNSCache struct {
+	// Resolver resolves the hosts.
+	Resolver HostResolver
+	// MinTTL is the minimum time between resolutions of a host.
+	MinTTL time.Duration
+
+	mu        sync.Mutex
+	entries   map[string]dnsCacheEntry
+	lastSweep time.Time
+}
+
+type dnsCacheEntry struct {
+	prefixes []string
+	expires  time.Time
+}
+
+// NewDNSCache returns a DNSCache resolving hosts with the resolver.
+func NewDNSCache(resolver HostResolver, minTTL time.Duration) *DNSCache {
+	return &DNSCache{Resolver: resolver, MinTTL: minTTL}
+}
+
+// Lookup returns the host prefixes of the host and the time it should be resolved again.
+// A failed resolution returns the previous answer, if any, along with the error.
+func (d *DNSCache) Lookup(ctx context.Context, host string) ([]string, time.Time, error) {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if errs := validation.IsDNS1123Subdomain(host); len(errs) > 0 {
+		return nil, time.Time{}, fmt.Errorf("invalid host %q: %s", host, strings.Join(errs, ", "))
+	}
+
+	now := time.Now()
+
+	d.mu.Lock()
+	entry, found := d.entries[host]
+	d.mu.Unlock()
+
+	if found && now.Before(entry.expires) {
+		return entry.prefixes, entry.expires, nil
+	}
+
+	addresses, ttl, err := d.Resolver.Resolve(ctx, host)
+	if err != nil {
+		// Keep the previous answer, and try again after the minimum TTL
+		entry.expires = now.Add(d.MinTTL)
+	} else {
+		entry = dnsCacheEntry{expires: now.Add(max(ttl, d.MinTTL))}
+		for _, address := range addresses {
+			entry.prefixes = append(entry.prefixes, hostPrefix(address))
+		}
+		entry.prefixes = sortSlice(entry.prefixes)
+	}
+
+	d.mu.Lock()
+	if d.entries == nil {
+		d.entries = map[string]dnsCacheEntry{}
+	}
+	d.entries[host] = entry
+	d.evictExpired(now)
+	d.mu.Unlock()
+
+	return entry.prefixes, entry.expires, err
+}
+
+// evictExpired removes the answers expired for longer than dnsCacheRetention.
+// It sweeps the entries at most once per retention period, and must be called with the lock held.
+func (d *DNSCache) evictExpired(now time.Time) {
+	if now.Sub(d.lastSweep) < dnsCacheRetention {
+		return
+	}
+	d.lastSweep = now
+
+	for host, entry := range d.entries {
+		if now.Sub(entry.expires) > dnsCacheRetention {
+			delete(d.entries, host)
+		}
+	}
+}
+
+// hostPrefix returns the /32 or /128 prefix of the address.
+func hostPrefix(address net.IP) string {
+	if ipv4 := address.To4(); ipv4 != nil {
+		return ipv4.String() + "/32"
+	}
+	return address.String() + "/128"
+}
@@ -0,0 +1,233 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// fakeDNSServer answers A and AAAA queries from its records on a local UDP port.
+type fakeDNSServer struct {
+	mu       sync.Mutex
+	records  map[string][]dns.RR
+	failures map[uint16]int
+	server   *dns.Server
+}
+
+func startFakeDNSServer(records map[string][]string) *fakeDNSServer {
+	fake := &fakeDNSServer{}
+	fake.setRecords(records)
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	Expect(err).NotTo(HaveOccurred())
+
+	started := make(chan struct{})
+	fake.server = &dns.Server{PacketConn: conn, Handler: fake, NotifyStartedFunc: func() { close(started) }}
+	go func() { _ = fake.server.ActivateAndServe() }()
+	<-started
+
+	return fake
+}
+
+// setRecords replaces the records, given in zone file format and keyed by host.
+func (f *fakeDNSServer) setRecords(records map[string][]string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.records = map[string][]dns.RR{}
+	for host, lines := range records {
+		for _, line := range lines {
+			record, err := dns.NewRR(line)
+			Expect(err).NotTo(HaveOccurred())
+			f.records[dns.Fqdn(host)] = append(f.records[dns.Fqdn(host)], record)
+		}
+	}
+}
+
+// setFailure answers queries of the record type with the rcode.
+func (f *fakeDNSServer) setFailure(recordType uint16, rcode int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.failures = map[uint16]int{recordType: rcode}
+}
+
+func (f *fakeDNSServer) ServeDNS(w dns.ResponseWriter, query *dns.Msg) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	response := new(dns.Msg)
+	response.SetReply(query)
+
+	question := query.Question[0]
+	if rcode, failed := f.failures[question.Qtype]; failed {
+		response.Rcode = rcode
+		_ = w.WriteMsg(response)
+		return
+	}
+
+	records, found := f.records[question.Name]
+	if !found {
+		response.Rcode = dns.RcodeNameError
+	}
+	for _, record := range records {
+		if record.Header().Rrtype == question.Qtype {
+			response.Answer = append(response.Answer, record)
+		}
+	}
+
+	_ = w.WriteMsg(response)
+}
+
+func (f *fakeDNSServer) address() string {
+	return f.server.PacketConn.LocalAddr().String()
+}
+
+var _ = Describe("DNS entries", func() {
+	ctx := context.Background()
+
+	var fake *fakeDNSServer
+
+	BeforeEach(func() {
+		fake = startFakeDNSServer(map[string][]string{
+			"partner.example.com": {
+				"partner.example.com. 300 IN A 192.0.2.10",
+				"partner.example.com. 120 IN A 192.0.2.11",
+				"partner.example.com. 600 IN AAAA 2001:db8::10",
+			},
+			"ipv4.example.com": {
+				"ipv4.example.com. 300 IN A 192.0.2.30",
+			},
+		})
+	})
+
+	AfterEach(func() {
+		Expect(fake.server.Shutdown()).To(Succeed())
+	})
+
+	It("should resolve A and AAAA records with the lowest TTL", func() {
+		resolver := &DNSServerResolver{Servers: []string{fake.address()}}
+
+		addresses, ttl, err := resolver.Resolve(ctx, "partner.example.com")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(addresses).To(HaveLen(3))
+		Expect(ttl).To(Equal(120 * time.Second))
+
+		_, _, err = resolver.Resolve(ctx, "missing.example.com")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should cache answers with a minimum TTL and keep them when resolution fails", func() {
+		cache := NewDNSCache(&DNSServerResolver{Servers: []string{fake.address()}}, 10*time.Minute)
+
+		prefixes, expires, err := cache.Lookup(ctx, "Partner.example.com.")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(prefixes).To(Equal([]string{"192.0.2.10/32", "192.0.2.11/32", "2001:db8::10/128"}))
+		Expect(expires).To(BeTemporally("~", time.Now().Add(10*time.Minute), time.Minute))
+
+		// The cached answer is used until it expires
+		fake.setRecords(nil)
+		prefixes, _, err = cache.Lookup(ctx, "partner.example.com")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(prefixes).To(HaveLen(3))
+
+		// An expired answer is kept when the host can't be resolved
+		cache.entries["partner.example.com"] = dnsCacheEntry{prefixes: prefixes, expires: time.Now()}
+		prefixes, expires, err = cache.Lookup(ctx, "partner.example.com")
+		Expect(err).To(HaveOccurred())
+		Expect(prefixes).To(HaveLen(3))
+		Expect(expires).To(BeTemporally("~", time.Now().Add(10*time.Minute), time.Minute))
+
+		_, _, err = cache.Lookup(ctx, "not_a_host")
+		Expect(err).To(MatchError(ContainSubstring("invalid host")))
+	})
+
+	It("should keep the previous answer when either address family fails", func() {
+		cache := NewDNSCache(&DNSServerResolver{Servers: []string{fake.address()}}, time.Minute)
+
+		prefixes, _, err := cache.Lookup(ctx, "partner.example.com")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(prefixes).To(HaveLen(3))
+
+		// Hosts without AAAA records still resolve, an empty answer is not a failure
+		ipv4Only, _, err := cache.Lookup(ctx, "ipv4.example.com")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ipv4Only).To(Equal([]string{"192.0.2.30/32"}))
+
+		// The A records alone are a partial answer, and don't replace the previous one
+		fake.setFailure(dns.TypeAAAA, dns.RcodeServerFailure)
+		_, _, err = (&DNSServerResolver{Servers: []string{fake.address()}}).Resolve(ctx, "partner.example.com")
+		Expect(err).To(MatchError(ContainSubstring("AAAA: SERVFAIL")))
+
+		cache.entries["partner.example.com"] = dnsCacheEntry{prefixes: prefixes, expires: time.Now()}
+		prefixes, _, err = cache.Lookup(ctx, "partner.example.com")
+		Expect(err).To(HaveOccurred())
+		Expect(prefixes).To(Equal([]string{"192.0.2.10/32", "192.0.2.11/32", "2001:db8::10/128"}))
+	})
+
+	It("should evict answers of hosts no longer looked up", func() {
+		cache := NewDNSCache(&DNSServerResolver{Servers: []string{fake.address()}}, time.Minute)
+
+		_, _, err := cache.Lookup(ctx, "partner.example.com")
+		Expect(err).NotTo(HaveOccurred())
+
+		stale := time.Now().Add(-2 * dnsCacheRetention)
+		cache.entries["stale.example.com"] = dnsCacheEntry{prefixes: []string{"192.0.2.20/32"}, expires: stale}
+		cache.entries["recent.example.com"] = dnsCacheEntry{prefixes: []string{"192.0.2.21/32"}, expires: time.Now()}
+		cache.lastSweep = stale
+
+		_, _, err = cache.Lookup(ctx, "ipv4.example.com")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cache.entries).To(HaveKey("partner.example.com"))
+		Expect(cache.entries).To(HaveKey("recent.example.com"))
+		Expect(cache.entries).NotTo(HaveKey("stale.example.com"))
+	})
+
+	It("should render resolved hosts and requeue when the answer expires", func() {
+		ingress := newTestIngress("dns-app", map[string]string{
+			AnnotationWhitelist: "10.0.0.0/24,dns:partner.example.com",
+		})
+		Expect(k8sClient.Create(ctx, ingress)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, ingress)).To(Succeed()) }()
+
+		ingressKey := types.NamespacedName{Name: ingress.Name, Namespace: ingress.Namespace}
+		controllerReconciler := &IngressReconciler{
+			Client: k8sClient,
+			Scheme: k8sClient.Scheme(),
+			Access: AccessConfig{
+				DNS: NewDNSCache(&DNSServerResolver{Servers: []string{fake.address()}}, time.Minute),
+			},
+		}
+		result, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: ingressKey})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(BeNumerically("~", 2*time.Minute, 5*time.Second))
+
+		updated := &networkingv1.Ingress{}
+		Expect(k8sClient.Get(ctx, ingressKey, updated)).To(Succeed())
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxWhitelist, "10.0.0.0/24,192.0.2.10/32,192.0.2.11/32,2001:db8::10/128"))
+	})
+})
@@ -0,0 +1,75 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	ingressnetworkpoliciesv1 "github.com/vitistack/ingressnetworkpolicy-operator/api/v1"
+)
+
+// EmergencyBlockReconciler reconciles a EmergencyBlock object
+type EmergencyBlockReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+}
+
+// +kubebuilder:rbac:groups=ingressnetworkpolicies.vitistack.io,resources=emergencyblocks,verbs=get;list;watch;create;update;patch;delete
+
+// Reconcile deletes EmergencyBlocks once they expire, and requeues them at their expiry until then.
+// Ingresses drop the block by themselves at its expiry, and are recomputed again through the deletion.
+func (r *EmergencyBlockReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := logf.FromContext(ctx)
+
+	var block ingressnetworkpoliciesv1.EmergencyBlock
+	if err := r.Get(ctx, req.NamespacedName, &block); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if until := time.Until(block.Spec.Expires.Time); until > 0 {
+		return ctrl.Result{RequeueAfter: until}, nil
+	}
+
+	log.Info("Emergency block expired", "Audit", true, "CIDR", block.Spec.CIDR, "RequestedBy", block.Spec.RequestedBy, "Reason", block.Spec.Reason)
+	if r.Recorder != nil {
+		r.Recorder.Eventf(&block, corev1.EventTypeNormal, EventReasonEmergencyBlockExpired, "Emergency block of %s expired", block.Spec.CIDR)
+	}
+
+	if err := r.Delete(ctx, &block); err != nil {
+		log.Error(err, "unable to delete expired EmergencyBlock", "EmergencyBlock.Name", block.Name)
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *EmergencyBlockReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&ingressnetworkpoliciesv1.EmergencyBlock{}).
+		Named("emergencyblock").
+		Complete(r)
+}
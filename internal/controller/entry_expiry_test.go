@@ -0,0 +1,142 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	ingressnetworkpoliciesv1 "github.com/vitistack/ingressnetworkpolicy-operator/api/v1"
+)
+
+var _ = Describe("Entry expiry", func() {
+	ctx := context.Background()
+
+	It("should parse entries with and without an expiry", func() {
+		value, expires, err := parseEntryExpiry("203.0.113.7/32@2026-11-01T00:00Z")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(value).To(Equal("203.0.113.7/32"))
+		Expect(expires).To(BeTemporally("==", time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)))
+
+		value, expires, err = parseEntryExpiry("dns:vpn.example.com@2026-11-01")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(value).To(Equal("dns:vpn.example.com"))
+		Expect(expires).To(BeTemporally("==", time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)))
+
+		value, expires, err = parseEntryExpiry("10.0.0.0/24")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(value).To(Equal("10.0.0.0/24"))
+		Expect(expires.IsZero()).To(BeTrue())
+
+		_, _, err = parseEntryExpiry("10.0.0.0/24@next-week")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should drop expired entries, requeue at the earliest expiry and emit an event once", func() {
+		ensureNamespace(ctx, DefaultNamespace)
+
+		soon := metav1.NewTime(time.Now().Add(time.Hour).Truncate(time.Second))
+		later := time.Now().Add(48 * time.Hour).UTC().Format(time.RFC3339)
+		past := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+
+		set := &ingressnetworkpoliciesv1.CIDRSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "support-sessions", Namespace: DefaultNamespace},
+			Spec: ingressnetworkpoliciesv1.CIDRSetSpec{Entries: []ingressnetworkpoliciesv1.CIDRSetEntry{
+				{CIDR: "198.51.100.0/24"},
+				{CIDR: "198.51.101.7/32", Expires: &soon, Description: "vendor support"},
+			}},
+		}
+		Expect(k8sClient.Create(ctx, set)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, set)).To(Succeed()) }()
+
+		ingress := newTestIngress("expiry-app", map[string]string{
+			AnnotationWhiteListNetworkPolicy: "cidrset:support-sessions",
+			AnnotationWhitelist:              "203.0.113.7/32@" + later + ",203.0.113.8/32@" + past,
+		})
+		Expect(k8sClient.Create(ctx, ingress)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, ingress)).To(Succeed()) }()
+
+		recorder := record.NewFakeRecorder(10)
+		ingressKey := types.NamespacedName{Name: ingress.Name, Namespace: ingress.Namespace}
+		controllerReconciler := &IngressReconciler{
+			Client: k8sClient,
+			Scheme: k8sClient.Scheme(),
+			Access: AccessConfig{Recorder: recorder},
+		}
+		result, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: ingressKey})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(BeNumerically("~", time.Until(soon.Time), time.Minute))
+
+		updated := &networkingv1.Ingress{}
+		Expect(k8sClient.Get(ctx, ingressKey, updated)).To(Succeed())
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxWhitelist, "198.51.100.0/24,198.51.101.7/32,203.0.113.7/32"))
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationExpiredEntries, "203.0.113.8/32@"+past))
+		Expect(recorder.Events).To(Receive(ContainSubstring(EventReasonAccessExpired)))
+
+		// An entry already recorded as expired is not announced again
+		_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: ingressKey})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(recorder.Events).NotTo(Receive())
+
+		Expect(controllerReconciler.ingressesForSource(SourceCIDRSet)(ctx, set)).To(ConsistOf(
+			reconcile.Request{NamespacedName: ingressKey},
+		))
+	})
+
+	It("should deny all access once the only allowlist entry expired", func() {
+		past := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+
+		ingress := newTestIngress("expired-only-app", map[string]string{AnnotationWhitelist: "203.0.113.7/32@" + past})
+		Expect(k8sClient.Create(ctx, ingress)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, ingress)).To(Succeed()) }()
+
+		recorder := record.NewFakeRecorder(10)
+		ingressKey := types.NamespacedName{Name: ingress.Name, Namespace: ingress.Namespace}
+		controllerReconciler := &IngressReconciler{
+			Client: k8sClient,
+			Scheme: k8sClient.Scheme(),
+			Access: AccessConfig{Recorder: recorder},
+		}
+		_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: ingressKey})
+		Expect(err).NotTo(HaveOccurred())
+
+		updated := &networkingv1.Ingress{}
+		Expect(k8sClient.Get(ctx, ingressKey, updated)).To(Succeed())
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxWhitelist, "0.0.0.0/32,::/128"))
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationDenyAll, denyAllEmptyAllowlist))
+		Expect(recorder.Events).To(Receive(ContainSubstring(EventReasonAccessExpired)))
+		Expect(recorder.Events).To(Receive(ContainSubstring(EventReasonDenyAll)))
+
+		// Removing the expired entry opens the Ingress again, as requested
+		delete(updated.Annotations, AnnotationWhitelist)
+		Expect(k8sClient.Update(ctx, updated)).To(Succeed())
+		_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: ingressKey})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(k8sClient.Get(ctx, ingressKey, updated)).To(Succeed())
+		Expect(updated.Annotations).NotTo(HaveKey(AnnotationNginxWhitelist))
+		Expect(updated.Annotations).NotTo(HaveKey(AnnotationDenyAll))
+	})
+})
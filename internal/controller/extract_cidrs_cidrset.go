@@ -0,0 +1,44 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ingressnetworkpoliciesv1 "github.com/vitistack/ingressnetworkpolicy-operator/api/v1"
+)
+
+// +kubebuilder:rbac:groups=ingressnetworkpolicies.vitistack.io,resources=cidrsets,verbs=get;list;watch
+
+// extractCIDRsFromCIDRSet resolves a cidrset: reference to the entries of the set, with their schedule and expiry appended.
+// Only sets in the Ingress namespace or the default namespace may be referenced, as authorized by the set.
+func extractCIDRsFromCIDRSet(ctx context.Context, r Getter, ingressNamespace string, path string) ([]string, error) {
+	namespace, name, ok := parseObjectReference(path)
+	if !ok {
+		return nil, fmt.Errorf("invalid %s reference %q, expected namespace/name", SourceCIDRSet, path)
+	}
+
+	if namespace != ingressNamespace && namespace != DefaultNamespace {
+		return nil, fmt.Errorf("%s %s/%s is outside namespace %s and %s", SourceCIDRSet, namespace, name, ingressNamespace, DefaultNamespace)
+	}
+
+	set := ingressnetworkpoliciesv1.CIDRSet{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, &set); err != nil {
+		return nil, err
+	}
+	if err := authorizeSource(ctx, r, &set, ingressNamespace); err != nil {
+		return nil, err
+	}
+
+	entries := make([]string, 0, len(set.Spec.Entries))
+	for _, entry := range set.Spec.Entries {
+		value := entry.CIDR
+		if entry.Schedule != "" {
+			value += ScheduleSeparator + entry.Schedule
+		}
+		entries = append(entries, formatEntryExpiry(value, entry.Expires))
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,131 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// +kubebuilder:rbac:groups="",resources=nodes,verbs=get;list;watch
+
+// parseSourceSelector parses the label selector of a node or service reference.
+// Requirements are separated by ; since the annotations are comma separated, and an empty selector selects everything.
+func parseSourceSelector(value string) (labels.Selector, error) {
+	return labels.Parse(strings.ReplaceAll(value, ";", ","))
+}
+
+// parseServiceReference parses a service-lb reference path of the form namespace/selector.
+func parseServiceReference(path string) (string, labels.Selector, error) {
+	namespace, selectorValue, found := strings.Cut(path, "/")
+	if !found || namespace == "" {
+		return "", nil, fmt.Errorf("invalid %s reference %q, expected namespace/selector", SourceServiceLoadBalancer, path)
+	}
+
+	selector, err := parseSourceSelector(selectorValue)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return namespace, selector, nil
+}
+
+// extractCIDRsFromNodes resolves a node-external-ip:, node-internal-ip: or node-pod-cidr: reference
+// to the addresses or pod CIDRs of the Nodes matching the selector.
+func extractCIDRsFromNodes(ctx context.Context, r client.Reader, kind string, path string) ([]string, error) {
+	selector, err := parseSourceSelector(path)
+	if err != nil {
+		return nil, err
+	}
+
+	nodeList := corev1.NodeList{}
+	if err := r.List(ctx, &nodeList, client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return nil, err
+	}
+
+	var cidrs []string
+	for _, node := range nodeList.Items {
+		switch kind {
+		case SourceNodePodCIDR:
+			cidrs = append(cidrs, node.Spec.PodCIDRs...)
+			if len(node.Spec.PodCIDRs) == 0 && node.Spec.PodCIDR != "" {
+				cidrs = append(cidrs, node.Spec.PodCIDR)
+			}
+		default:
+			addressType := corev1.NodeExternalIP
+			if kind == SourceNodeInternalIP {
+				addressType = corev1.NodeInternalIP
+			}
+			for _, address := range node.Status.Addresses {
+				if address.Type != addressType {
+					continue
+				}
+				if prefix, ok := normalizePrefix(address.Address); ok {
+					cidrs = append(cidrs, prefix)
+				}
+			}
+		}
+	}
+
+	return cidrs, nil
+}
+
+// extractCIDRsFromServices resolves a service-lb: reference to the load balancer addresses
+// of the Services matching the selector. Only Services in the Ingress namespace or the default namespace may be referenced.
+func extractCIDRsFromServices(ctx context.Context, r client.Reader, ingressNamespace string, path string) ([]string, error) {
+	namespace, selector, err := parseServiceReference(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if namespace != ingressNamespace && namespace != DefaultNamespace {
+		return nil, fmt.Errorf("%s %s is outside namespace %s and %s", SourceServiceLoadBalancer, namespace, ingressNamespace, DefaultNamespace)
+	}
+
+	serviceList := corev1.ServiceList{}
+	if err := r.List(ctx, &serviceList, client.InNamespace(namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return nil, err
+	}
+
+	var cidrs []string
+	for _, service := range serviceList.Items {
+		for _, loadBalancerIngress := range service.Status.LoadBalancer.Ingress {
+			if prefix, ok := normalizePrefix(loadBalancerIngress.IP); ok {
+				cidrs = append(cidrs, prefix)
+			}
+		}
+	}
+
+	return cidrs, nil
+}
+
+// ingressSelectsSourceObject reports whether one of the reference annotations of the Ingress
+// has a node or service reference of the given kind selecting the object.
+func ingressSelectsSourceObject(annotations map[string]string, kind string, obj client.Object) bool {
+	for _, annotation := range ingressReferenceAnnotations {
+		for _, reference := range policyReferences(annotations[annotation]) {
+			referenceKind, path := splitSourceReference(reference)
+			if referenceKind != kind {
+				continue
+			}
+
+			selectorValue := path
+			if kind == SourceServiceLoadBalancer {
+				namespace, value, _ := strings.Cut(path, "/")
+				if namespace != obj.GetNamespace() {
+					continue
+				}
+				selectorValue = value
+			}
+
+			selector, err := parseSourceSelector(selectorValue)
+			if err == nil && selector.Matches(labels.Set(obj.GetLabels())) {
+				return true
+			}
+		}
+	}
+	return false
+}
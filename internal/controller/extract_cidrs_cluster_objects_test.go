@@ -0,0 +1,107 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var _ = Describe("Node and Service sources", func() {
+	ctx := context.Background()
+
+	newNode := func(name string, role string, external string, internal string, podCIDR string) *corev1.Node {
+		node := &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"role": role, "zone": "a"}},
+			Spec:       corev1.NodeSpec{PodCIDR: podCIDR, PodCIDRs: []string{podCIDR}},
+		}
+		Expect(k8sClient.Create(ctx, node)).To(Succeed())
+		node.Status.Addresses = []corev1.NodeAddress{
+			{Type: corev1.NodeExternalIP, Address: external},
+			{Type: corev1.NodeInternalIP, Address: internal},
+			{Type: corev1.NodeHostName, Address: name},
+		}
+		Expect(k8sClient.Status().Update(ctx, node)).To(Succeed())
+		return node
+	}
+
+	It("should resolve node addresses, pod CIDRs and load balancer IPs", func() {
+		egress := newNode("egress-1", "egress", "203.0.113.1", "10.0.0.1", "10.244.1.0/24")
+		worker := newNode("worker-1", "worker", "203.0.113.2", "10.0.0.2", "10.244.2.0/24")
+		defer func() {
+			Expect(k8sClient.Delete(ctx, egress)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, worker)).To(Succeed())
+		}()
+
+		service := &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "nat-gateway", Namespace: "default", Labels: map[string]string{"app": "nat"}},
+			Spec: corev1.ServiceSpec{
+				Type:  corev1.ServiceTypeLoadBalancer,
+				Ports: []corev1.ServicePort{{Port: 443}},
+			},
+		}
+		Expect(k8sClient.Create(ctx, service)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, service)).To(Succeed()) }()
+		service.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: "198.51.100.9"}, {Hostname: "lb.example.com"}}
+		Expect(k8sClient.Status().Update(ctx, service)).To(Succeed())
+
+		ingress := newTestIngress("cluster-sources-app", map[string]string{
+			AnnotationWhiteListNetworkPolicy: "node-external-ip:role=egress;zone=a,node-pod-cidr:role=worker,service-lb:default/app=nat",
+			AnnotationDenyListNetworkPolicy:  "node-internal-ip:role=worker,service-lb:kube-system/app=nat",
+		})
+		Expect(k8sClient.Create(ctx, ingress)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, ingress)).To(Succeed()) }()
+
+		ingressKey := types.NamespacedName{Name: ingress.Name, Namespace: ingress.Namespace}
+		controllerReconciler := &IngressReconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+		_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: ingressKey})
+		Expect(err).NotTo(HaveOccurred())
+
+		updated := &networkingv1.Ingress{}
+		Expect(k8sClient.Get(ctx, ingressKey, updated)).To(Succeed())
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxWhitelist, "10.244.2.0/24,198.51.100.9/32,203.0.113.1/32"))
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxDenylist, "10.0.0.2/32"))
+
+		// Nodes entering or leaving the selection are mapped to the Ingress
+		mapNodes := controllerReconciler.ingressesSelectingSource(SourceNodeExternalIP, SourceNodeInternalIP, SourceNodePodCIDR)
+		Expect(mapNodes(ctx, egress)).To(ConsistOf(reconcile.Request{NamespacedName: ingressKey}))
+		unrelated := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "db-1", Labels: map[string]string{"role": "db"}}}
+		Expect(mapNodes(ctx, unrelated)).To(BeEmpty())
+		Expect(controllerReconciler.ingressesSelectingSource(SourceServiceLoadBalancer)(ctx, service)).To(ConsistOf(
+			reconcile.Request{NamespacedName: ingressKey},
+		))
+	})
+
+	It("should ignore node heartbeats", func() {
+		oldNode := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node", Labels: map[string]string{"role": "egress"}}}
+		heartbeat := oldNode.DeepCopy()
+		heartbeat.Status.Conditions = []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}}
+		Expect(nodeSourceChangedPredicate.Update(event.UpdateEvent{ObjectOld: oldNode, ObjectNew: heartbeat})).To(BeFalse())
+
+		readdressed := oldNode.DeepCopy()
+		readdressed.Status.Addresses = []corev1.NodeAddress{{Type: corev1.NodeExternalIP, Address: "203.0.113.5"}}
+		Expect(nodeSourceChangedPredicate.Update(event.UpdateEvent{ObjectOld: oldNode, ObjectNew: readdressed})).To(BeTrue())
+	})
+})
@@ -0,0 +1,80 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// +kubebuilder:rbac:groups="",resources=configmaps;secrets,verbs=get;list;watch
+
+// extractCIDRsFromObjectKey resolves a configmap: or secret: reference to the CIDRs listed in the key.
+// Only objects in the Ingress namespace or the default namespace may be referenced, as authorized by the object.
+// Secrets of the default namespace must also opt in with the cidr-source label, since it holds the credentials of
+// feeds and NetBox sources, and the operator must not read them on behalf of Ingresses.
+func extractCIDRsFromObjectKey(ctx context.Context, r Getter, ingressNamespace string, kind string, path string) ([]string, error) {
+	namespace, name, key, ok := parseObjectKeyReference(path)
+	if !ok {
+		return nil, fmt.Errorf("invalid %s reference %q, expected namespace/name/key", kind, path)
+	}
+
+	if namespace != ingressNamespace && namespace != DefaultNamespace {
+		return nil, fmt.Errorf("%s %s/%s is outside namespace %s and %s", kind, namespace, name, ingressNamespace, DefaultNamespace)
+	}
+
+	var data string
+	var found bool
+
+	switch kind {
+	case SourceConfigMap:
+		configMap := corev1.ConfigMap{}
+		if err := r.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, &configMap); err != nil {
+			return nil, err
+		}
+		if err := authorizeSource(ctx, r, &configMap, ingressNamespace); err != nil {
+			return nil, err
+		}
+		data, found = configMap.Data[key]
+	case SourceSecret:
+		secret := corev1.Secret{}
+		if err := r.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, &secret); err != nil {
+			return nil, err
+		}
+		if namespace != ingressNamespace && secret.Labels[LabelCIDRSource] != "true" {
+			return nil, fmt.Errorf("%s %s/%s without label %s=true is %w %s", kind, namespace, name, LabelCIDRSource, errUnauthorizedSource, ingressNamespace)
+		}
+		if err := authorizeSource(ctx, r, &secret, ingressNamespace); err != nil {
+			return nil, err
+		}
+		var value []byte
+		value, found = secret.Data[key]
+		data = string(value)
+	}
+
+	if !found {
+		return nil, fmt.Errorf("key %q not found in %s %s/%s", key, kind, namespace, name)
+	}
+
+	return parseCIDRList(data), nil
+}
+
+// parseCIDRList parses newline or comma separated entries, ignoring comments starting with #.
+func parseCIDRList(data string) []string {
+	var entries []string
+
+	for _, line := range strings.Split(data, "\n") {
+		if index := strings.Index(line, "#"); index >= 0 {
+			line = line[:index]
+		}
+		for _, entry := range filterSliceFromString(strings.Split(line, ",")) {
+			if entry != "" {
+				entries = append(entries, entry)
+			}
+		}
+	}
+
+	return entries
+}
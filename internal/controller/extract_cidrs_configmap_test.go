@@ -0,0 +1,154 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var _ = Describe("ConfigMap and Secret sources", func() {
+	ctx := context.Background()
+
+	It("should parse comma and newline separated entries with comments", func() {
+		data := "# offices\n10.0.0.0/24, 10.0.1.0/24 # oslo\n\n192.0.2.0/24\n"
+		Expect(parseCIDRList(data)).To(Equal([]string{"10.0.0.0/24", "10.0.1.0/24", "192.0.2.0/24"}))
+	})
+
+	It("should match Ingresses referencing the source", func() {
+		annotations := map[string]string{
+			AnnotationWhiteListNetworkPolicy: "office, configmap:default/ranges/offices",
+			AnnotationDenyListNetworkPolicy:  "secret:blocked/list",
+		}
+		Expect(ingressReferencesSource(annotations, SourceConfigMap, "default", "ranges")).To(BeTrue())
+		Expect(ingressReferencesSource(annotations, SourceSecret, DefaultNamespace, "blocked")).To(BeTrue())
+		Expect(ingressReferencesSource(annotations, SourceSecret, "default", "ranges")).To(BeFalse())
+	})
+
+	It("should resolve CIDRs from ConfigMaps and Secrets", func() {
+		configMap := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "source-ranges", Namespace: "default"},
+			Data:       map[string]string{"offices": "10.60.0.0/24\n10.61.0.0/24 # bergen\nnot-a-cidr"},
+		}
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "source-ranges", Namespace: "default"},
+			Data:       map[string][]byte{"partners": []byte("192.0.2.0/24")},
+		}
+		Expect(k8sClient.Create(ctx, configMap)).To(Succeed())
+		Expect(k8sClient.Create(ctx, secret)).To(Succeed())
+		defer func() {
+			Expect(k8sClient.Delete(ctx, configMap)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, secret)).To(Succeed())
+		}()
+
+		ingress := newTestIngress("source-app", map[string]string{
+			AnnotationWhiteListNetworkPolicy: "configmap:default/source-ranges/offices,secret:default/source-ranges/partners,configmap:kube-system/other/key",
+		})
+		Expect(k8sClient.Create(ctx, ingress)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, ingress)).To(Succeed()) }()
+
+		ingressKey := types.NamespacedName{Name: ingress.Name, Namespace: ingress.Namespace}
+		controllerReconciler := &IngressReconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+		_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: ingressKey})
+		Expect(err).NotTo(HaveOccurred())
+
+		updated := &networkingv1.Ingress{}
+		Expect(k8sClient.Get(ctx, ingressKey, updated)).To(Succeed())
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxWhitelist, "10.60.0.0/24,10.61.0.0/24,192.0.2.0/24"))
+
+		Expect(controllerReconciler.ingressesForSource(SourceConfigMap)(ctx, configMap)).To(ConsistOf(
+			reconcile.Request{NamespacedName: ingressKey},
+		))
+	})
+	It("should never show the values of invalid Secret entries on the Ingress or in events", func() {
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "source-credentials", Namespace: "default"},
+			Data:       map[string][]byte{"token": []byte("192.0.2.0/24\nhunter2\ns3cret@never\n203.0.113.0/24@2020-01-01")},
+		}
+		Expect(k8sClient.Create(ctx, secret)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, secret)).To(Succeed()) }()
+
+		ingress := newTestIngress("source-credentials-app", map[string]string{
+			AnnotationWhiteListNetworkPolicy: "secret:default/source-credentials/token",
+		})
+		Expect(k8sClient.Create(ctx, ingress)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, ingress)).To(Succeed()) }()
+
+		recorder := record.NewFakeRecorder(10)
+		ingressKey := types.NamespacedName{Name: ingress.Name, Namespace: ingress.Namespace}
+		controllerReconciler := &IngressReconciler{Client: k8sClient, Scheme: k8sClient.Scheme(), Access: AccessConfig{Recorder: recorder}}
+		_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: ingressKey})
+		Expect(err).NotTo(HaveOccurred())
+
+		updated := &networkingv1.Ingress{}
+		Expect(k8sClient.Get(ctx, ingressKey, updated)).To(Succeed())
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxWhitelist, "192.0.2.0/24"))
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationUnresolvedSources, "secret:default/source-credentials/token (2 invalid)"))
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationExpiredEntries, "secret:default/source-credentials/token (1 expired)"))
+		for key, value := range updated.Annotations {
+			Expect(value).NotTo(Or(ContainSubstring("hunter2"), ContainSubstring("s3cret"), ContainSubstring("203.0.113.0")), key)
+		}
+
+		close(recorder.Events)
+		Expect(recorder.Events).NotTo(BeEmpty())
+		for event := range recorder.Events {
+			Expect(event).NotTo(Or(ContainSubstring("hunter2"), ContainSubstring("s3cret"), ContainSubstring("203.0.113.0")))
+		}
+	})
+	It("should only read Secrets of the operator namespace labeled as CIDR sources", func() {
+		ensureNamespace(ctx, DefaultNamespace)
+
+		credentials := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "feed-auth", Namespace: DefaultNamespace},
+			Data:       map[string][]byte{"token": []byte("10.74.0.0/24")},
+		}
+		shared := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "partner-ranges", Namespace: DefaultNamespace, Labels: map[string]string{LabelCIDRSource: "true"}},
+			Data:       map[string][]byte{"ranges": []byte("10.75.0.0/24")},
+		}
+		Expect(k8sClient.Create(ctx, credentials)).To(Succeed())
+		Expect(k8sClient.Create(ctx, shared)).To(Succeed())
+		defer func() {
+			Expect(k8sClient.Delete(ctx, credentials)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, shared)).To(Succeed())
+		}()
+
+		ingress := newTestIngress("source-secret-label-app", map[string]string{
+			AnnotationWhiteListNetworkPolicy: "secret:feed-auth/token,secret:partner-ranges/ranges",
+		})
+		Expect(k8sClient.Create(ctx, ingress)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, ingress)).To(Succeed()) }()
+
+		ingressKey := types.NamespacedName{Name: ingress.Name, Namespace: ingress.Namespace}
+		controllerReconciler := &IngressReconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+		_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: ingressKey})
+		Expect(err).NotTo(HaveOccurred())
+
+		updated := &networkingv1.Ingress{}
+		Expect(k8sClient.Get(ctx, ingressKey, updated)).To(Succeed())
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxWhitelist, "10.75.0.0/24"))
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationUnauthorizedPolicies, "secret:feed-auth/token"))
+	})
+})
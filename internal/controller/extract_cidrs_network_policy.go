@@ -2,18 +2,23 @@ package controller
 
 import (
 	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // extractCIDRsFromNetworkPolicy extracts all unique CIDRs from the given NetworkPolicy's ingress rules.
 // It appends any new CIDRs found to the provided cidrs slice and returns the updated slice.
+// When ports is not nil, only rules allowing one of the ports are considered.
 
-func extractCIDRsFromNetworkPolicy(np *networkingv1.NetworkPolicy, cidrs []string) []string {
+func extractCIDRsFromNetworkPolicy(np *networkingv1.NetworkPolicy, cidrs []string, ports []intstr.IntOrString) []string {
 	seen := make(map[string]struct{}, len(cidrs))
 	for _, c := range cidrs {
 		seen[c] = struct{}{}
 	}
 
 	for _, ingress := range np.Spec.Ingress {
+		if !ruleMatchesPorts(ingress, ports) {
+			continue
+		}
 		for _, from := range ingress.From {
 			if from.IPBlock != nil && from.IPBlock.CIDR != "" {
 				c := from.IPBlock.CIDR
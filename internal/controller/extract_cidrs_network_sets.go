@@ -0,0 +1,120 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// +kubebuilder:rbac:groups="crd.projectcalico.org",resources=globalnetworksets;networksets,verbs=get;list;watch
+// +kubebuilder:rbac:groups="cilium.io",resources=ciliumcidrgroups,verbs=get;list;watch
+
+// networkSetSource describes a CIDR set object of a CNI. The objects are read as unstructured,
+// so the operator does not depend on the CNI being installed.
+type networkSetSource struct {
+	// kind is the reference kind of the source.
+	kind string
+	// groupKind of the object.
+	groupKind schema.GroupKind
+	// versions of the object, in order of preference.
+	versions []string
+	// namespaced objects are restricted to the Ingress namespace and the default namespace.
+	namespaced bool
+	// field is the path to the list of CIDRs in the object.
+	field []string
+}
+
+// networkSetSources are the CIDR set objects of the supported CNIs.
+var networkSetSources = []networkSetSource{
+	{
+		kind:      SourceCalicoGlobalNetworkSet,
+		groupKind: schema.GroupKind{Group: "crd.projectcalico.org", Kind: "GlobalNetworkSet"},
+		versions:  []string{"v1"},
+		field:     []string{"spec", "nets"},
+	},
+	{
+		kind:       SourceCalicoNetworkSet,
+		groupKind:  schema.GroupKind{Group: "crd.projectcalico.org", Kind: "NetworkSet"},
+		versions:   []string{"v1"},
+		namespaced: true,
+		field:      []string{"spec", "nets"},
+	},
+	{
+		kind:      SourceCiliumCIDRGroup,
+		groupKind: schema.GroupKind{Group: "cilium.io", Kind: "CiliumCIDRGroup"},
+		versions:  []string{"v2", "v2alpha1"},
+		field:     []string{"spec", "externalCIDRs"},
+	},
+}
+
+// findNetworkSetSource returns the network set source of the reference kind.
+func findNetworkSetSource(kind string) (networkSetSource, bool) {
+	for _, source := range networkSetSources {
+		if source.kind == kind {
+			return source, true
+		}
+	}
+	return networkSetSource{}, false
+}
+
+// installedVersion returns the first version of the source known to the RESTMapper,
+// or false when the CNI is not installed.
+func (s networkSetSource) installedVersion(mapper meta.RESTMapper) (schema.GroupVersionKind, bool) {
+	for _, version := range s.versions {
+		if _, err := mapper.RESTMapping(s.groupKind, version); err == nil {
+			return s.groupKind.WithVersion(version), true
+		}
+	}
+	return schema.GroupVersionKind{}, false
+}
+
+// extractCIDRsFromNetworkSet resolves a calico-gns:, calico-ns: or cilium-cidrgroup: reference
+// to the CIDRs of the object, trying each version of the object in turn. Objects outside the Ingress namespace
+// may restrict the namespaces referencing them like shared NetworkPolicies.
+func extractCIDRsFromNetworkSet(ctx context.Context, r Getter, ingressNamespace string, source networkSetSource, path string) ([]string, error) {
+	key := client.ObjectKey{Name: path}
+	if source.namespaced {
+		namespace, name, ok := parseObjectReference(path)
+		if !ok {
+			return nil, fmt.Errorf("invalid %s reference %q, expected namespace/name", source.kind, path)
+		}
+		if namespace != ingressNamespace && namespace != DefaultNamespace {
+			return nil, fmt.Errorf("%s %s/%s is outside namespace %s and %s", source.kind, namespace, name, ingressNamespace, DefaultNamespace)
+		}
+		key = client.ObjectKey{Namespace: namespace, Name: name}
+	}
+
+	var err error
+	for _, version := range source.versions {
+		object := &unstructured.Unstructured{}
+		object.SetGroupVersionKind(source.groupKind.WithVersion(version))
+
+		// Versions not served by the cluster don't match, try the next one
+		if err = r.Get(ctx, key, object); err != nil {
+			if meta.IsNoMatchError(err) || apierrors.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+
+		if err := authorizeSource(ctx, r, object, ingressNamespace); err != nil {
+			return nil, err
+		}
+
+		cidrs, _, err := unstructured.NestedStringSlice(object.Object, source.field...)
+		if err != nil {
+			return nil, err
+		}
+		return cidrs, nil
+	}
+
+	if meta.IsNoMatchError(err) {
+		return nil, fmt.Errorf("%s is not installed: %w", source.groupKind, err)
+	}
+	return nil, err
+}
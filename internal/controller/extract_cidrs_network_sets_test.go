@@ -0,0 +1,79 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var _ = Describe("Calico and Cilium network set sources", func() {
+	ctx := context.Background()
+
+	newNetworkSet := func(apiVersion string, kind string, namespace string, name string, field string, cidrs ...any) *unstructured.Unstructured {
+		object := &unstructured.Unstructured{Object: map[string]any{
+			"apiVersion": apiVersion,
+			"kind":       kind,
+			"metadata":   map[string]any{"name": name},
+			"spec":       map[string]any{field: cidrs},
+		}}
+		if namespace != "" {
+			object.SetNamespace(namespace)
+		}
+		Expect(k8sClient.Create(ctx, object)).To(Succeed())
+		return object
+	}
+
+	It("should resolve CIDRs from Calico network sets and Cilium CIDR groups", func() {
+		globalNetworkSet := newNetworkSet("crd.projectcalico.org/v1", "GlobalNetworkSet", "", "corp-offices", "nets", "10.90.0.0/16", "192.0.2.1")
+		networkSet := newNetworkSet("crd.projectcalico.org/v1", "NetworkSet", "default", "partners", "nets", "198.51.100.0/24")
+		cidrGroup := newNetworkSet("cilium.io/v2alpha1", "CiliumCIDRGroup", "", "vpn", "externalCIDRs", "172.16.0.0/20")
+		defer func() {
+			Expect(k8sClient.Delete(ctx, globalNetworkSet)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, networkSet)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, cidrGroup)).To(Succeed())
+		}()
+
+		ingress := newTestIngress("network-sets-app", map[string]string{
+			AnnotationWhiteListNetworkPolicy: "calico-gns:corp-offices,calico-ns:default/partners,calico-ns:kube-system/partners",
+			AnnotationDenyListNetworkPolicy:  "cilium-cidrgroup:vpn",
+		})
+		Expect(k8sClient.Create(ctx, ingress)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, ingress)).To(Succeed()) }()
+
+		ingressKey := types.NamespacedName{Name: ingress.Name, Namespace: ingress.Namespace}
+		controllerReconciler := &IngressReconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+		_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: ingressKey})
+		Expect(err).NotTo(HaveOccurred())
+
+		updated := &networkingv1.Ingress{}
+		Expect(k8sClient.Get(ctx, ingressKey, updated)).To(Succeed())
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxWhitelist, "10.90.0.0/16,192.0.2.1/32,198.51.100.0/24"))
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxDenylist, "172.16.0.0/20"))
+
+		Expect(controllerReconciler.ingressesForSource(SourceCalicoGlobalNetworkSet)(ctx, globalNetworkSet)).To(ConsistOf(
+			reconcile.Request{NamespacedName: ingressKey},
+		))
+		Expect(controllerReconciler.ingressesForSource(SourceCiliumCIDRGroup)(ctx, globalNetworkSet)).To(BeEmpty())
+	})
+})
@@ -0,0 +1,206 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/util/jsonpath"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ingressnetworkpoliciesv1 "github.com/vitistack/ingressnetworkpolicy-operator/api/v1"
+)
+
+// maxFeedSize limits the size of documents fetched from CIDR feeds.
+const maxFeedSize = 16 << 20
+
+// fetchCIDRFeed fetches the feed and returns the sorted prefixes found in the document.
+// A feed without any prefixes is an error, so an empty response never replaces the last good data.
+func fetchCIDRFeed(ctx context.Context, r Getter, httpClient *http.Client, feed *ingressnetworkpoliciesv1.CIDRFeed) ([]string, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, feed.Spec.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if feed.Spec.AuthSecretRef != nil {
+		if err := setFeedAuth(ctx, r, feed.Namespace, feed.Spec.AuthSecretRef.Name, request); err != nil {
+			return nil, err
+		}
+	}
+
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = response.Body.Close() }()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s from %s", response.Status, feed.Spec.URL)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(response.Body, maxFeedSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > maxFeedSize {
+		return nil, fmt.Errorf("feed %s is larger than %d bytes", feed.Spec.URL, maxFeedSize)
+	}
+
+	entries, err := parseCIDRFeed(feed.Spec, body)
+	if err != nil {
+		return nil, err
+	}
+
+	var prefixes []string
+	for _, entry := range entries {
+		if prefix, ok := normalizePrefix(entry); ok {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+
+	if len(prefixes) == 0 {
+		return nil, fmt.Errorf("no prefixes found in feed %s", feed.Spec.URL)
+	}
+
+	return sortSlice(prefixes), nil
+}
+
+// setFeedAuth adds the credentials from the Secret to the request,
+// either a bearer token or basic auth.
+func setFeedAuth(ctx context.Context, r Getter, namespace string, name string, request *http.Request) error {
+	secret := corev1.Secret{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, &secret); err != nil {
+		return err
+	}
+
+	if token, ok := secret.Data["token"]; ok {
+		request.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+		return nil
+	}
+
+	username, hasUsername := secret.Data["username"]
+	password, hasPassword := secret.Data["password"]
+	if hasUsername && hasPassword {
+		request.SetBasicAuth(string(username), string(password))
+		return nil
+	}
+
+	return fmt.Errorf("secret %s/%s has neither token nor username and password", namespace, name)
+}
+
+// parseCIDRFeed extracts the raw entries from the feed document according to its format.
+func parseCIDRFeed(spec ingressnetworkpoliciesv1.CIDRFeedSpec, body []byte) ([]string, error) {
+	switch spec.Format {
+	case ingressnetworkpoliciesv1.CIDRFeedFormatJSON:
+		return parseJSONFeed(spec.JSONPath, body)
+	case ingressnetworkpoliciesv1.CIDRFeedFormatCSV:
+		return parseCSVFeed(spec.CSVColumn, body)
+	default:
+		return parseCIDRList(string(body)), nil
+	}
+}
+
+// parseJSONFeed returns the strings selected by the JSONPath expression.
+func parseJSONFeed(expression string, body []byte) ([]string, error) {
+	if !strings.HasPrefix(expression, "{") {
+		expression = "{" + expression + "}"
+	}
+
+	var data any
+	if err := json.Unmarshal(body, &data); err != nil {
+		return nil, err
+	}
+
+	path := jsonpath.New("feed").AllowMissingKeys(true)
+	if err := path.Parse(expression); err != nil {
+		return nil, err
+	}
+
+	results, err := path.FindResults(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []string
+	for _, result := range results {
+		for _, value := range result {
+			if entry, ok := value.Interface().(string); ok {
+				entries = append(entries, entry)
+			}
+		}
+	}
+
+	return entries, nil
+}
+
+// parseCSVFeed returns the values of the column, skipping rows that are too short.
+// Header rows are dropped later since they are not valid prefixes.
+func parseCSVFeed(column int, body []byte) ([]string, error) {
+	reader := csv.NewReader(bytes.NewReader(body))
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []string
+	for _, record := range records {
+		if column < len(record) {
+			entries = append(entries, record[column])
+		}
+	}
+
+	return entries, nil
+}
+
+// normalizePrefix accepts a prefix or a single address, which is turned into a host prefix.
+func normalizePrefix(entry string) (string, bool) {
+	entry = strings.TrimSpace(entry)
+
+	if _, _, err := net.ParseCIDR(entry); err == nil {
+		return entry, true
+	}
+
+	ip := net.ParseIP(entry)
+	if ip == nil {
+		return "", false
+	}
+	if ip.To4() != nil {
+		return entry + "/32", true
+	}
+	return entry + "/128", true
+}
+
+// extractCIDRsFromFeed resolves a feed: reference to the last good prefixes of the CIDRFeed.
+// Only feeds in the Ingress namespace or the default namespace may be referenced, as authorized by the feed,
+// and feeds that were never fetched or have gone stale are an error.
+func extractCIDRsFromFeed(ctx context.Context, r Getter, ingressNamespace string, path string) ([]string, error) {
+	namespace, name, ok := parseObjectReference(path)
+	if !ok {
+		return nil, fmt.Errorf("invalid %s reference %q, expected namespace/name", SourceFeed, path)
+	}
+
+	if namespace != ingressNamespace && namespace != DefaultNamespace {
+		return nil, fmt.Errorf("%s %s/%s is outside namespace %s and %s", SourceFeed, namespace, name, ingressNamespace, DefaultNamespace)
+	}
+
+	feed := ingressnetworkpoliciesv1.CIDRFeed{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, &feed); err != nil {
+		return nil, err
+	}
+	if err := authorizeSource(ctx, r, &feed, ingressNamespace); err != nil {
+		return nil, err
+	}
+
+	return cidrSourcePrefixes(SourceFeed, namespace, name, &feed.Status, feed.Spec.MaxAge)
+}
@@ -0,0 +1,160 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ingressnetworkpoliciesv1 "github.com/vitistack/ingressnetworkpolicy-operator/api/v1"
+)
+
+const (
+	// netboxPageSize is the number of prefixes requested per page.
+	netboxPageSize = 1000
+	// maxNetBoxPages guards against a NetBox instance paging forever.
+	maxNetBoxPages = 1000
+)
+
+// netboxPrefixPage is a page of the NetBox prefix list.
+type netboxPrefixPage struct {
+	Next    *string `json:"next"`
+	Results []struct {
+		Prefix string `json:"prefix"`
+	} `json:"results"`
+}
+
+// fetchNetBoxPrefixes queries the prefixes matching the filters of the source from the NetBox REST API,
+// following the pages of the result. A query without any prefixes is an error,
+// so an empty response never replaces the last good data.
+func fetchNetBoxPrefixes(ctx context.Context, r Getter, httpClient *http.Client, source *ingressnetworkpoliciesv1.NetBoxPrefixSource) ([]string, error) {
+	secret := corev1.Secret{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: source.Namespace, Name: source.Spec.TokenSecretRef.Name}, &secret); err != nil {
+		return nil, err
+	}
+	token, found := secret.Data["token"]
+	if !found {
+		return nil, fmt.Errorf("secret %s/%s has no token", source.Namespace, source.Spec.TokenSecretRef.Name)
+	}
+
+	base, err := url.Parse(strings.TrimSuffix(source.Spec.URL, "/") + "/api/ipam/prefixes/")
+	if err != nil {
+		return nil, err
+	}
+
+	query := url.Values{}
+	for _, tag := range source.Spec.Tags {
+		query.Add("tag", tag)
+	}
+	for _, role := range source.Spec.Roles {
+		query.Add("role", role)
+	}
+	for _, site := range source.Spec.Sites {
+		query.Add("site", site)
+	}
+	for _, vrf := range source.Spec.VRFs {
+		query.Add("vrf", vrf)
+	}
+	if source.Spec.Status != "" {
+		query.Set("status", source.Spec.Status)
+	}
+	query.Set("limit", fmt.Sprint(netboxPageSize))
+	base.RawQuery = query.Encode()
+
+	var prefixes []string
+	next := base
+
+	for page := 0; next != nil; page++ {
+		if page == maxNetBoxPages {
+			return nil, fmt.Errorf("more than %d pages of prefixes from %s", maxNetBoxPages, source.Spec.URL)
+		}
+
+		result, err := getNetBoxPrefixPage(ctx, httpClient, strings.TrimSpace(string(token)), next.String())
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range result.Results {
+			if prefix, ok := normalizePrefix(item.Prefix); ok {
+				prefixes = append(prefixes, prefix)
+			}
+		}
+
+		next = nil
+		if result.Next != nil && *result.Next != "" {
+			nextURL, err := url.Parse(*result.Next)
+			if err != nil {
+				return nil, err
+			}
+			// The token is only sent to the configured instance
+			if nextURL.Host != base.Host {
+				return nil, fmt.Errorf("refusing to follow next page on host %s", nextURL.Host)
+			}
+			nextURL.Scheme = base.Scheme
+			next = nextURL
+		}
+	}
+
+	if len(prefixes) == 0 {
+		return nil, fmt.Errorf("no prefixes found in %s matching %s", source.Spec.URL, base.RawQuery)
+	}
+
+	return sortSlice(prefixes), nil
+}
+
+// getNetBoxPrefixPage fetches a single page of the NetBox prefix list.
+func getNetBoxPrefixPage(ctx context.Context, httpClient *http.Client, token string, pageURL string) (*netboxPrefixPage, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Authorization", "Token "+token)
+	request.Header.Set("Accept", "application/json")
+
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = response.Body.Close() }()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s from %s", response.Status, pageURL)
+	}
+
+	page := netboxPrefixPage{}
+	if err := json.NewDecoder(io.LimitReader(response.Body, maxFeedSize)).Decode(&page); err != nil {
+		return nil, err
+	}
+
+	return &page, nil
+}
+
+// extractCIDRsFromNetBox resolves a netbox: reference to the last good prefixes of the NetBoxPrefixSource.
+// Only sources in the Ingress namespace or the default namespace may be referenced, as authorized by the source,
+// and sources that were never fetched or have gone stale are an error.
+func extractCIDRsFromNetBox(ctx context.Context, r Getter, ingressNamespace string, path string) ([]string, error) {
+	namespace, name, ok := parseObjectReference(path)
+	if !ok {
+		return nil, fmt.Errorf("invalid %s reference %q, expected namespace/name", SourceNetBox, path)
+	}
+
+	if namespace != ingressNamespace && namespace != DefaultNamespace {
+		return nil, fmt.Errorf("%s %s/%s is outside namespace %s and %s", SourceNetBox, namespace, name, ingressNamespace, DefaultNamespace)
+	}
+
+	source := ingressnetworkpoliciesv1.NetBoxPrefixSource{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, &source); err != nil {
+		return nil, err
+	}
+	if err := authorizeSource(ctx, r, &source, ingressNamespace); err != nil {
+		return nil, err
+	}
+
+	return cidrSourcePrefixes(SourceNetBox, namespace, name, &source.Status, source.Spec.MaxAge)
+}
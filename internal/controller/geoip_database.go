@@ -0,0 +1,272 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/oschwald/maxminddb-golang/v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// GeoIPDatabase resolves geo: entries to the prefixes of a country from a MaxMind or DB-IP country database.
+// The prefixes of a country are aggregated and cached until the database file changes.
+// It is a manager Runnable polling the file, and announces reloads on Changes.
+type GeoIPDatabase struct {
+	// Path of the MMDB file.
+	Path string
+	// MaxPrefixes is the maximum number of aggregated prefixes of a country, larger countries are refused in allowlists
+	// and covered by shorter prefixes in denylists.
+	MaxPrefixes int
+	// ReloadInterval is the time between checks of the file for changes.
+	ReloadInterval time.Duration
+
+	mu        sync.RWMutex
+	reader    *maxminddb.Reader
+	modTime   time.Time
+	size      int64
+	countries map[string][]netip.Prefix
+	changes   chan event.GenericEvent
+}
+
+// NewGeoIPDatabase returns a GeoIPDatabase for the MMDB file, loading it immediately.
+func NewGeoIPDatabase(path string, maxPrefixes int, reloadInterval time.Duration) (*GeoIPDatabase, error) {
+	database := &GeoIPDatabase{
+		Path:           path,
+		MaxPrefixes:    maxPrefixes,
+		ReloadInterval: reloadInterval,
+		changes:        make(chan event.GenericEvent, 1),
+	}
+	if _, err := database.reload(); err != nil {
+		return nil, err
+	}
+	return database, nil
+}
+
+// Changes returns the channel announcing reloads of the database.
+func (g *GeoIPDatabase) Changes() <-chan event.GenericEvent {
+	return g.changes
+}
+
+// Start polls the database file and reloads it when it changes, until the context is done.
+func (g *GeoIPDatabase) Start(ctx context.Context) error {
+	log := logf.FromContext(ctx).WithName("geoip")
+
+	ticker := time.NewTicker(g.ReloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			g.mu.Lock()
+			defer g.mu.Unlock()
+			return g.reader.Close()
+		case <-ticker.C:
+			reloaded, err := g.reload()
+			if err != nil {
+				log.Error(err, "unable to reload GeoIP database, keeping the loaded database", "Path", g.Path)
+				continue
+			}
+			if reloaded {
+				log.Info("Reloaded GeoIP database", "Path", g.Path)
+				// A pending announcement covers this reload too
+				select {
+				case g.changes <- event.GenericEvent{Object: &metav1.PartialObjectMetadata{}}:
+				default:
+				}
+			}
+		}
+	}
+}
+
+// reload opens the database file when it changed since it was loaded, and clears the cached countries.
+func (g *GeoIPDatabase) reload() (bool, error) {
+	info, err := os.Stat(g.Path)
+	if err != nil {
+		return false, err
+	}
+
+	g.mu.RLock()
+	unchanged := g.reader != nil && info.ModTime().Equal(g.modTime) && info.Size() == g.size
+	g.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	reader, err := maxminddb.Open(g.Path)
+	if err != nil {
+		return false, err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.reader != nil {
+		_ = g.reader.Close()
+	}
+	g.reader = reader
+	g.modTime = info.ModTime()
+	g.size = info.Size()
+	g.countries = map[string][]netip.Prefix{}
+
+	return true, nil
+}
+
+// Lookup returns the aggregated prefixes of the country, by ISO 3166-1 alpha-2 code.
+// Countries with more than MaxPrefixes prefixes are refused.
+func (g *GeoIPDatabase) Lookup(country string) ([]string, error) {
+	networks, err := g.countryNetworks(country)
+	if err != nil {
+		return nil, err
+	}
+	if g.MaxPrefixes > 0 && len(networks) > g.MaxPrefixes {
+		return nil, fmt.Errorf("country %s has %d prefixes, more than the maximum of %d", strings.ToUpper(strings.TrimSpace(country)), len(networks), g.MaxPrefixes)
+	}
+	return prefixStrings(networks), nil
+}
+
+// LookupCovering returns prefixes covering the country, by ISO 3166-1 alpha-2 code. Countries with more than
+// MaxPrefixes prefixes are covered by shorter prefixes including addresses of other countries, so it is only
+// suited for denylists.
+func (g *GeoIPDatabase) LookupCovering(country string) ([]string, error) {
+	networks, err := g.countryNetworks(country)
+	if err != nil {
+		return nil, err
+	}
+	return prefixStrings(coarsenPrefixes(networks, g.MaxPrefixes)), nil
+}
+
+// countryNetworks returns the aggregated prefixes of the country, walking the database on the first lookup.
+func (g *GeoIPDatabase) countryNetworks(country string) ([]netip.Prefix, error) {
+	country = strings.ToUpper(strings.TrimSpace(country))
+	if len(country) != 2 {
+		return nil, fmt.Errorf("invalid country code %q", country)
+	}
+
+	g.mu.RLock()
+	networks, found := g.countries[country]
+	g.mu.RUnlock()
+	if found {
+		return networks, nil
+	}
+
+	// Walking the database is slow, so the write lock keeps concurrent lookups from doing it twice
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if networks, found := g.countries[country]; found {
+		return networks, nil
+	}
+
+	for result := range g.reader.Networks() {
+		if err := result.Err(); err != nil {
+			return nil, err
+		}
+		var isoCode string
+		if err := result.DecodePath(&isoCode, "country", "iso_code"); err != nil {
+			return nil, err
+		}
+		if isoCode == country {
+			networks = append(networks, result.Prefix())
+		}
+	}
+
+	networks = aggregatePrefixes(networks)
+	if len(networks) == 0 {
+		return nil, fmt.Errorf("no prefixes found for country %s", country)
+	}
+	g.countries[country] = networks
+
+	return networks, nil
+}
+
+// prefixStrings formats the prefixes.
+func prefixStrings(prefixes []netip.Prefix) []string {
+	var cidrs []string
+	for _, prefix := range prefixes {
+		cidrs = append(cidrs, prefix.String())
+	}
+	return cidrs
+}
+
+// coarsenPrefixes shortens the longest prefixes by one bit and aggregates them, until there are at most maxPrefixes.
+// The result covers every address of the prefixes. IPv4 prefixes are compared to IPv6 prefixes as IPv4-mapped addresses.
+func coarsenPrefixes(prefixes []netip.Prefix, maxPrefixes int) []netip.Prefix {
+	mappedBits := func(prefix netip.Prefix) int {
+		if prefix.Addr().Is4() {
+			return prefix.Bits() + 96
+		}
+		return prefix.Bits()
+	}
+
+	for maxPrefixes > 0 && len(prefixes) > maxPrefixes {
+		longest := 0
+		for _, prefix := range prefixes {
+			longest = max(longest, mappedBits(prefix))
+		}
+		shortened := make([]netip.Prefix, 0, len(prefixes))
+		for _, prefix := range prefixes {
+			if mappedBits(prefix) == longest && prefix.Bits() > 0 {
+				prefix = netip.PrefixFrom(prefix.Addr(), prefix.Bits()-1).Masked()
+			}
+			shortened = append(shortened, prefix)
+		}
+		prefixes = aggregatePrefixes(shortened)
+	}
+
+	return prefixes
+}
+
+// aggregatePrefixes removes prefixes contained in others and merges adjacent prefixes into their parent.
+func aggregatePrefixes(prefixes []netip.Prefix) []netip.Prefix {
+	sorted := make([]netip.Prefix, 0, len(prefixes))
+	for _, prefix := range prefixes {
+		sorted = append(sorted, prefix.Masked())
+	}
+	slices.SortFunc(sorted, func(a, b netip.Prefix) int {
+		if c := a.Addr().Compare(b.Addr()); c != 0 {
+			return c
+		}
+		return a.Bits() - b.Bits()
+	})
+
+	var aggregated []netip.Prefix
+	for _, prefix := range sorted {
+		if len(aggregated) > 0 && aggregated[len(aggregated)-1].Overlaps(prefix) {
+			continue
+		}
+		aggregated = append(aggregated, prefix)
+
+		// Merge the last two prefixes while they are the halves of the same parent
+		for len(aggregated) >= 2 {
+			lower, upper := aggregated[len(aggregated)-2], aggregated[len(aggregated)-1]
+			if lower.Bits() != upper.Bits() || lower.Bits() == 0 || lower.Addr().Is4() != upper.Addr().Is4() {
+				break
+			}
+			parent := netip.PrefixFrom(lower.Addr(), lower.Bits()-1).Masked()
+			if parent.Addr() != lower.Addr() || !parent.Contains(upper.Addr()) {
+				break
+			}
+			aggregated = append(aggregated[:len(aggregated)-2], parent)
+		}
+	}
+
+	return aggregated
+}
+
+// ingressUsesGeoIP reports whether the custom entries in the annotations of an Ingress have geo: entries.
+func ingressUsesGeoIP(annotations map[string]string) bool {
+	for _, annotation := range []string{AnnotationWhitelist, AnnotationDenylist} {
+		if strings.Contains(annotations[annotation], EntryPrefixGeo) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,198 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/maxmind/mmdbwriter"
+	"github.com/maxmind/mmdbwriter/mmdbtype"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// writeCountryDatabase writes a country MMDB file mapping the networks to ISO country codes.
+// The file is replaced atomically, like volumes mounted from ConfigMaps, since the loaded database is memory mapped.
+func writeCountryDatabase(path string, countries map[string]string) {
+	tree, err := mmdbwriter.New(mmdbwriter.Options{
+		DatabaseType:            "GeoLite2-Country",
+		IncludeReservedNetworks: true,
+	})
+	Expect(err).NotTo(HaveOccurred())
+
+	for network, country := range countries {
+		_, ipNet, err := net.ParseCIDR(network)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(tree.Insert(ipNet, mmdbtype.Map{
+			"country": mmdbtype.Map{"iso_code": mmdbtype.String(country)},
+		})).To(Succeed())
+	}
+
+	file, err := os.Create(path + ".tmp")
+	Expect(err).NotTo(HaveOccurred())
+	_, err = tree.WriteTo(file)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(file.Close()).To(Succeed())
+	Expect(os.Rename(path+".tmp", path)).To(Succeed())
+}
+
+var _ = Describe("GeoIP entries", func() {
+	ctx := context.Background()
+
+	var path string
+
+	BeforeEach(func() {
+		path = filepath.Join(GinkgoT().TempDir(), "country.mmdb")
+		writeCountryDatabase(path, map[string]string{
+			"192.0.2.0/25":    "NO",
+			"192.0.2.128/26":  "NO",
+			"192.0.2.192/26":  "NO",
+			"2001:db8::/32":   "NO",
+			"198.51.100.0/24": "SE",
+		})
+	})
+
+	It("should aggregate adjacent and contained prefixes", func() {
+		prefixes := []netip.Prefix{
+			netip.MustParsePrefix("10.0.0.0/25"),
+			netip.MustParsePrefix("10.0.0.128/25"),
+			netip.MustParsePrefix("10.0.1.0/24"),
+			netip.MustParsePrefix("10.0.1.7/32"),
+			netip.MustParsePrefix("10.0.3.0/24"),
+		}
+		Expect(aggregatePrefixes(prefixes)).To(Equal([]netip.Prefix{
+			netip.MustParsePrefix("10.0.0.0/23"),
+			netip.MustParsePrefix("10.0.3.0/24"),
+		}))
+	})
+
+	It("should resolve countries, cap them and reload when the file changes", func() {
+		database, err := NewGeoIPDatabase(path, 2, 10*time.Millisecond)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(database.Lookup("no")).To(Equal([]string{"192.0.2.0/24", "2001:db8::/32"}))
+		Expect(database.Lookup("SE")).To(Equal([]string{"198.51.100.0/24"}))
+		_, err = database.Lookup("DK")
+		Expect(err).To(MatchError(ContainSubstring("no prefixes found")))
+		_, err = database.Lookup("norway")
+		Expect(err).To(MatchError(ContainSubstring("invalid country code")))
+
+		runCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		go func() { _ = database.Start(runCtx) }()
+
+		writeCountryDatabase(path, map[string]string{
+			"192.0.2.0/26":    "NO",
+			"192.0.2.128/26":  "NO",
+			"203.0.113.0/24":  "NO",
+			"198.51.100.0/24": "SE",
+		})
+		Expect(os.Chtimes(path, time.Now(), time.Now().Add(time.Minute))).To(Succeed())
+
+		Eventually(database.Changes()).Should(Receive())
+		_, err = database.Lookup("NO")
+		Expect(err).To(MatchError(ContainSubstring("more than the maximum of 2")))
+
+		// Denylists cover large countries with shorter prefixes instead
+		Expect(database.LookupCovering("NO")).To(Equal([]string{"192.0.2.0/24", "203.0.113.0/24"}))
+	})
+
+	It("should cover every address when coarsening prefixes", func() {
+		prefixes := []netip.Prefix{
+			netip.MustParsePrefix("10.0.0.0/24"),
+			netip.MustParsePrefix("10.0.2.0/24"),
+			netip.MustParsePrefix("10.0.5.0/24"),
+			netip.MustParsePrefix("2001:db8::/48"),
+		}
+		Expect(coarsenPrefixes(prefixes, 3)).To(Equal([]netip.Prefix{
+			netip.MustParsePrefix("10.0.0.0/22"),
+			netip.MustParsePrefix("10.0.4.0/23"),
+			netip.MustParsePrefix("2001:db8::/48"),
+		}))
+		Expect(coarsenPrefixes(prefixes, 0)).To(HaveLen(4))
+	})
+
+	It("should refuse large countries in allowlists and cover them in denylists", func() {
+		writeCountryDatabase(path, map[string]string{
+			"192.0.2.0/26":   "NO",
+			"192.0.2.128/26": "NO",
+			"203.0.113.0/24": "NO",
+		})
+		database, err := NewGeoIPDatabase(path, 2, time.Minute)
+		Expect(err).NotTo(HaveOccurred())
+
+		ingress := newTestIngress("geo-large-app", map[string]string{
+			AnnotationWhitelist: "geo:NO,10.0.0.0/24",
+			AnnotationDenylist:  "geo:NO",
+		})
+		Expect(k8sClient.Create(ctx, ingress)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, ingress)).To(Succeed()) }()
+
+		recorder := record.NewFakeRecorder(10)
+		ingressKey := types.NamespacedName{Name: ingress.Name, Namespace: ingress.Namespace}
+		controllerReconciler := &IngressReconciler{
+			Client: k8sClient,
+			Scheme: k8sClient.Scheme(),
+			Access: AccessConfig{GeoIP: database, Recorder: recorder},
+		}
+		_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: ingressKey})
+		Expect(err).NotTo(HaveOccurred())
+
+		updated := &networkingv1.Ingress{}
+		Expect(k8sClient.Get(ctx, ingressKey, updated)).To(Succeed())
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxWhitelist, "10.0.0.0/24"))
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxDenylist, "192.0.2.0/24,203.0.113.0/24"))
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationUnresolvedSources, "geo:NO"))
+		Expect(recorder.Events).To(Receive(ContainSubstring(EventReasonUnresolvedSource)))
+	})
+
+	It("should render countries on Ingresses", func() {
+		database, err := NewGeoIPDatabase(path, 100, time.Minute)
+		Expect(err).NotTo(HaveOccurred())
+
+		ingress := newTestIngress("geo-app", map[string]string{
+			AnnotationWhitelist: "geo:NO,10.0.0.0/24",
+			AnnotationDenylist:  "geo:SE",
+		})
+		Expect(k8sClient.Create(ctx, ingress)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, ingress)).To(Succeed()) }()
+
+		ingressKey := types.NamespacedName{Name: ingress.Name, Namespace: ingress.Namespace}
+		controllerReconciler := &IngressReconciler{
+			Client: k8sClient,
+			Scheme: k8sClient.Scheme(),
+			Access: AccessConfig{GeoIP: database},
+		}
+		_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: ingressKey})
+		Expect(err).NotTo(HaveOccurred())
+
+		updated := &networkingv1.Ingress{}
+		Expect(k8sClient.Get(ctx, ingressKey, updated)).To(Succeed())
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxWhitelist, "10.0.0.0/24,192.0.2.0/24,2001:db8::/32"))
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxDenylist, "198.51.100.0/24"))
+		Expect(controllerReconciler.ingressesUsingGeoIP(ctx, nil)).To(ConsistOf(reconcile.Request{NamespacedName: ingressKey}))
+	})
+})
@@ -0,0 +1,130 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ingressnetworkpoliciesv1 "github.com/vitistack/ingressnetworkpolicy-operator/api/v1"
+)
+
+// errGuardrailViolation is returned when an allowlist violates the AccessGuardrails of its namespace.
+var errGuardrailViolation = errors.New("allowlist violates guardrails")
+
+// +kubebuilder:rbac:groups=ingressnetworkpolicies.vitistack.io,resources=accessguardrails,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+
+// ValidateIngressGuardrails checks the CIDRs of the custom whitelist entries of the Ingress
+// against the AccessGuardrails applying to its namespace. References and dns: and geo: entries
+// are only known once resolved, and are checked by the reconcilers.
+func ValidateIngressGuardrails(ctx context.Context, r client.Reader, ingress *v1.Ingress) error {
+	var cidrs []string
+	for _, entry := range filterSliceFromString(strings.Split(ingress.GetAnnotations()[AnnotationWhitelist], ",")) {
+		value, _, err := parseEntryExpiry(entry)
+		if err != nil {
+			return err
+		}
+		value, _ = splitScheduleReference(value)
+		if checkValidCIDR(value) {
+			cidrs = append(cidrs, value)
+		}
+	}
+	return checkGuardrails(ctx, r, ingress.Namespace, cidrs)
+}
+
+// checkGuardrails checks the allowlist against the AccessGuardrails applying to the namespace.
+// Violations are returned wrapping errGuardrailViolation.
+func checkGuardrails(ctx context.Context, r client.Reader, namespace string, allow []string) error {
+	if len(allow) == 0 {
+		return nil
+	}
+
+	guardrails, err := guardrailsForNamespace(ctx, r, namespace)
+	if err != nil {
+		return err
+	}
+
+	var violations []string
+	for _, guardrail := range guardrails {
+		violations = append(violations, guardrailViolations(guardrail, parsePrefixes(allow))...)
+	}
+
+	if len(violations) > 0 {
+		return fmt.Errorf("%w: %s", errGuardrailViolation, strings.Join(violations, "; "))
+	}
+	return nil
+}
+
+// guardrailsForNamespace returns the AccessGuardrails selecting the namespace.
+func guardrailsForNamespace(ctx context.Context, r client.Reader, namespace string) ([]ingressnetworkpoliciesv1.AccessGuardrail, error) {
+	guardrailList := ingressnetworkpoliciesv1.AccessGuardrailList{}
+	if err := r.List(ctx, &guardrailList); err != nil {
+		return nil, err
+	}
+
+	var namespaceLabels labels.Set
+	var guardrails []ingressnetworkpoliciesv1.AccessGuardrail
+	for _, guardrail := range guardrailList.Items {
+		if guardrail.Spec.NamespaceSelector == nil {
+			guardrails = append(guardrails, guardrail)
+			continue
+		}
+
+		selector, err := metav1.LabelSelectorAsSelector(guardrail.Spec.NamespaceSelector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid namespaceSelector of AccessGuardrail %s: %w", guardrail.Name, err)
+		}
+
+		if namespaceLabels == nil {
+			ns := corev1.Namespace{}
+			if err := r.Get(ctx, client.ObjectKey{Name: namespace}, &ns); err != nil {
+				return nil, err
+			}
+			namespaceLabels = labels.Set(ns.Labels)
+		}
+
+		if selector.Matches(namespaceLabels) {
+			guardrails = append(guardrails, guardrail)
+		}
+	}
+
+	return guardrails, nil
+}
+
+// guardrailViolations describes the prefixes violating the guardrail.
+func guardrailViolations(guardrail ingressnetworkpoliciesv1.AccessGuardrail, prefixes []netip.Prefix) []string {
+	spec := guardrail.Spec
+	forbidden := parsePrefixes(spec.ForbiddenCIDRs)
+	allowed := parsePrefixes(spec.AllowedCIDRs)
+
+	var violations []string
+	for _, prefix := range prefixes {
+		minPrefixLength := spec.MinPrefixLengthIPv6
+		if prefix.Addr().Is4() {
+			minPrefixLength = spec.MinPrefixLengthIPv4
+		}
+		if minPrefixLength != nil && prefix.Bits() < int(*minPrefixLength) {
+			violations = append(violations, fmt.Sprintf("%s is broader than /%d (AccessGuardrail %s)", prefix, *minPrefixLength, guardrail.Name))
+		}
+
+		for _, forbiddenPrefix := range forbidden {
+			if prefix.Overlaps(forbiddenPrefix) {
+				violations = append(violations, fmt.Sprintf("%s overlaps forbidden range %s (AccessGuardrail %s)", prefix, forbiddenPrefix, guardrail.Name))
+			}
+		}
+
+		if len(spec.AllowedCIDRs) > 0 && len(subtractPrefixes([]netip.Prefix{prefix}, allowed)) > 0 {
+			violations = append(violations, fmt.Sprintf("%s is outside the allowed ranges (AccessGuardrail %s)", prefix, guardrail.Name))
+		}
+	}
+
+	return violations
+}
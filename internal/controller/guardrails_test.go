@@ -0,0 +1,152 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	ingressnetworkpoliciesv1 "github.com/vitistack/ingressnetworkpolicy-operator/api/v1"
+)
+
+var _ = Describe("Access guardrails", func() {
+	ctx := context.Background()
+
+	var guardrails []*ingressnetworkpoliciesv1.AccessGuardrail
+
+	BeforeEach(func() {
+		ensureNamespace(ctx, "default")
+		tenant := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "tenant-x", Labels: map[string]string{"tenant": "x"}}}
+		Expect(client.IgnoreAlreadyExists(k8sClient.Create(ctx, tenant))).To(Succeed())
+
+		guardrails = []*ingressnetworkpoliciesv1.AccessGuardrail{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+				Spec: ingressnetworkpoliciesv1.AccessGuardrailSpec{
+					MinPrefixLengthIPv4: ptr.To[int32](8),
+					MinPrefixLengthIPv6: ptr.To[int32](32),
+					ForbiddenCIDRs:      []string{"169.254.0.0/16"},
+				},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "tenant-x"},
+				Spec: ingressnetworkpoliciesv1.AccessGuardrailSpec{
+					NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"tenant": "x"}},
+					AllowedCIDRs:      []string{"10.0.0.0/8"},
+				},
+			},
+		}
+		for _, guardrail := range guardrails {
+			Expect(k8sClient.Create(ctx, guardrail)).To(Succeed())
+		}
+	})
+
+	AfterEach(func() {
+		for _, guardrail := range guardrails {
+			Expect(k8sClient.Delete(ctx, guardrail)).To(Succeed())
+		}
+	})
+
+	It("should refuse broad, forbidden and out of universe entries", func() {
+		Expect(checkGuardrails(ctx, k8sClient, "default", []string{"10.0.0.0/8", "192.0.2.0/24", "2001:db8::/32"})).To(Succeed())
+
+		err := checkGuardrails(ctx, k8sClient, "default", []string{"0.0.0.0/0", "169.254.169.254/32", "::/0"})
+		Expect(errors.Is(err, errGuardrailViolation)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("0.0.0.0/0 is broader than /8"))
+		Expect(err.Error()).To(ContainSubstring("169.254.169.254/32 overlaps forbidden range 169.254.0.0/16"))
+		Expect(err.Error()).To(ContainSubstring("::/0 is broader than /32"))
+
+		// The universe only applies to the selected namespaces
+		Expect(checkGuardrails(ctx, k8sClient, "tenant-x", []string{"10.20.0.0/16"})).To(Succeed())
+		err = checkGuardrails(ctx, k8sClient, "tenant-x", []string{"10.20.0.0/16", "192.0.2.0/24"})
+		Expect(err).To(MatchError(ContainSubstring("192.0.2.0/24 is outside the allowed ranges (AccessGuardrail tenant-x)")))
+	})
+
+	It("should validate the custom whitelist entries of an Ingress at admission", func() {
+		ingress := newTestIngress("guarded-app", map[string]string{
+			AnnotationWhitelist: "dns:vpn.example.com,10.0.0.0/24@2026-12-31,0.0.0.0/1~maintenance",
+		})
+		Expect(ValidateIngressGuardrails(ctx, k8sClient, ingress)).To(MatchError(ContainSubstring("0.0.0.0/1 is broader than /8")))
+
+		ingress.Annotations[AnnotationWhitelist] = "dns:vpn.example.com,10.0.0.0/24@2026-12-31"
+		Expect(ValidateIngressGuardrails(ctx, k8sClient, ingress)).To(Succeed())
+	})
+
+	It("should keep the rendered allowlist, render the denylist and emit an event when the allowlist violates a guardrail", func() {
+		mandatory := &ingressnetworkpoliciesv1.MandatoryDenylist{
+			ObjectMeta: metav1.ObjectMeta{Name: "guardrail-security"},
+			Spec:       ingressnetworkpoliciesv1.MandatoryDenylistSpec{Entries: []string{"203.0.113.0/24"}},
+		}
+		Expect(k8sClient.Create(ctx, mandatory)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, mandatory)).To(Succeed()) }()
+
+		ingress := newTestIngress("violating-app", map[string]string{
+			AnnotationWhitelist:      "0.0.0.0/0",
+			AnnotationDenylist:       "10.0.0.7/32",
+			AnnotationNginxWhitelist: "10.0.0.0/24",
+		})
+		Expect(k8sClient.Create(ctx, ingress)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, ingress)).To(Succeed()) }()
+
+		recorder := record.NewFakeRecorder(10)
+		ingressKey := types.NamespacedName{Name: ingress.Name, Namespace: ingress.Namespace}
+		controllerReconciler := &IngressReconciler{
+			Client: k8sClient,
+			Scheme: k8sClient.Scheme(),
+			Access: AccessConfig{Recorder: recorder},
+		}
+		_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: ingressKey})
+		Expect(err).NotTo(HaveOccurred())
+
+		updated := &networkingv1.Ingress{}
+		Expect(k8sClient.Get(ctx, ingressKey, updated)).To(Succeed())
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxWhitelist, "10.0.0.0/24"))
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxDenylist, "10.0.0.7/32,203.0.113.0/24"))
+		Expect(recorder.Events).To(Receive(ContainSubstring(EventReasonGuardrailViolation)))
+
+		Expect(controllerReconciler.ingressesWithAccess(ctx, guardrails[0])).To(ContainElement(
+			reconcile.Request{NamespacedName: ingressKey},
+		))
+	})
+
+	It("should deny all access when the allowlist violates a guardrail before any allowlist was rendered", func() {
+		ingress := newTestIngress("violating-new-app", map[string]string{AnnotationWhitelist: "0.0.0.0/0"})
+		Expect(k8sClient.Create(ctx, ingress)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, ingress)).To(Succeed()) }()
+
+		ingressKey := types.NamespacedName{Name: ingress.Name, Namespace: ingress.Namespace}
+		controllerReconciler := &IngressReconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+		_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: ingressKey})
+		Expect(err).NotTo(HaveOccurred())
+
+		updated := &networkingv1.Ingress{}
+		Expect(k8sClient.Get(ctx, ingressKey, updated)).To(Succeed())
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxWhitelist, "0.0.0.0/32,::/128"))
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationDenyAll, denyAllRefusedAllowlist))
+	})
+})
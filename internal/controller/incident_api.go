@@ -0,0 +1,384 @@
+package controller
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	ingressnetworkpoliciesv1 "github.com/vitistack/ingressnetworkpolicy-operator/api/v1"
+)
+
+var (
+	// errUnauthenticated is returned for requests without a valid bearer token.
+	errUnauthenticated = errors.New("unauthenticated")
+	// errForbidden is returned for users without the RBAC permission for the request.
+	errForbidden = errors.New("forbidden")
+)
+
+// IncidentAuthorizer authenticates the bearer token of an incident API request,
+// and authorizes the user for the verb on EmergencyBlocks. It returns the name of the user.
+type IncidentAuthorizer interface {
+	Authorize(ctx context.Context, token string, verb string) (string, error)
+}
+
+// TokenReviewAuthorizer authorizes incident API requests with TokenReviews and SubjectAccessReviews,
+// so callers need the same RBAC permissions as for managing EmergencyBlocks directly.
+type TokenReviewAuthorizer struct {
+	Client client.Client
+}
+
+// +kubebuilder:rbac:groups=authentication.k8s.io,resources=tokenreviews,verbs=create
+// +kubebuilder:rbac:groups=authorization.k8s.io,resources=subjectaccessreviews,verbs=create
+
+// Authorize reviews the token and checks that its user may use the verb on emergencyblocks.
+func (a TokenReviewAuthorizer) Authorize(ctx context.Context, token string, verb string) (string, error) {
+	tokenReview := &authenticationv1.TokenReview{Spec: authenticationv1.TokenReviewSpec{Token: token}}
+	if err := a.Client.Create(ctx, tokenReview); err != nil {
+		return "", err
+	}
+	if !tokenReview.Status.Authenticated {
+		return "", errUnauthenticated
+	}
+
+	user := tokenReview.Status.User
+	extra := map[string]authorizationv1.ExtraValue{}
+	for key, value := range user.Extra {
+		extra[key] = authorizationv1.ExtraValue(value)
+	}
+	accessReview := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:   user.Username,
+			UID:    user.UID,
+			Groups: user.Groups,
+			Extra:  extra,
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Group:    ingressnetworkpoliciesv1.GroupVersion.Group,
+				Resource: "emergencyblocks",
+				Verb:     verb,
+			},
+		},
+	}
+	if err := a.Client.Create(ctx, accessReview); err != nil {
+		return "", err
+	}
+	if !accessReview.Status.Allowed {
+		return user.Username, errForbidden
+	}
+
+	return user.Username, nil
+}
+
+// EmergencyBlockRequest is the body of an incident API request adding an EmergencyBlock.
+type EmergencyBlockRequest struct {
+	// CIDR is the address or range to block, f.ex 203.0.113.7 or 203.0.113.0/24.
+	CIDR string `json:"cidr"`
+	// TTL is how long the block lasts, f.ex 30m. The API default is used when empty.
+	TTL string `json:"ttl,omitempty"`
+	// Reason is why the range is blocked.
+	Reason string `json:"reason"`
+}
+
+// IncidentAPI serves the incident-response API, adding and removing EmergencyBlocks
+// applied to every managed Ingress:
+//
+//	GET    /v1/blocks            lists the EmergencyBlocks
+//	POST   /v1/blocks            adds a block from an EmergencyBlockRequest, or renews it
+//	DELETE /v1/blocks?cidr=CIDR  removes a block
+//
+// The blocks are persisted as EmergencyBlocks, so every replica may serve the API.
+type IncidentAPI struct {
+	Client     client.Client
+	Authorizer IncidentAuthorizer
+	// Recorder emits events on the EmergencyBlocks. No events are emitted when unset.
+	Recorder record.EventRecorder
+	// BindAddress is the address the API listens on.
+	BindAddress string
+	// TLSConfig serves the API with TLS.
+	TLSConfig *tls.Config
+	// Insecure serves plain HTTP when TLSConfig is unset, Start fails without TLS otherwise.
+	Insecure bool
+	// DefaultTTL is the TTL of requests without one.
+	DefaultTTL time.Duration
+	// MaxTTL is the longest TTL accepted, any TTL is accepted when zero.
+	MaxTTL time.Duration
+	// MinPrefixLengthIPv4 and MinPrefixLengthIPv6 limit the broadest ranges that can be blocked,
+	// any range is accepted when zero.
+	MinPrefixLengthIPv4 int
+	MinPrefixLengthIPv6 int
+}
+
+// NeedLeaderElection serves the API on every replica, the blocks are written to the API server.
+func (a *IncidentAPI) NeedLeaderElection() bool {
+	return false
+}
+
+// Start serves the API until the context is done.
+func (a *IncidentAPI) Start(ctx context.Context) error {
+	log := logf.FromContext(ctx).WithName("incident-api")
+
+	// Bearer tokens would be sent in clear text
+	if a.TLSConfig == nil && !a.Insecure {
+		return errors.New("the incident API requires TLS, configure a certificate or allow plain HTTP explicitly")
+	}
+
+	listener, err := net.Listen("tcp", a.BindAddress)
+	if err != nil {
+		return err
+	}
+	if a.TLSConfig != nil {
+		listener = tls.NewListener(listener, a.TLSConfig)
+	} else {
+		log.Info("serving the incident API without TLS, bearer tokens are sent in clear text")
+	}
+
+	server := &http.Server{Handler: a.Handler(), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	log.Info("serving the incident API", "Address", a.BindAddress)
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Handler returns the HTTP handler of the API.
+func (a *IncidentAPI) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/blocks", a.authorized("list", a.listBlocks))
+	mux.HandleFunc("POST /v1/blocks", a.authorized("create", a.addBlock))
+	mux.HandleFunc("DELETE /v1/blocks", a.authorized("delete", a.removeBlock))
+	return mux
+}
+
+// authorized authorizes requests for the verb before passing them and the user to the handler.
+func (a *IncidentAPI) authorized(verb string, handler func(http.ResponseWriter, *http.Request, string)) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if user, ok := a.authorize(w, req, verb); ok {
+			handler(w, req, user)
+		}
+	}
+}
+
+// authorize authorizes the request for the verb and returns the user.
+// Unauthorized requests are answered, and false is returned.
+func (a *IncidentAPI) authorize(w http.ResponseWriter, req *http.Request, verb string) (string, bool) {
+	token, found := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+	if !found || strings.TrimSpace(token) == "" {
+		http.Error(w, "missing bearer token", http.StatusUnauthorized)
+		return "", false
+	}
+
+	user, err := a.Authorizer.Authorize(req.Context(), strings.TrimSpace(token), verb)
+	switch {
+	case errors.Is(err, errUnauthenticated):
+		http.Error(w, "invalid bearer token", http.StatusUnauthorized)
+		return "", false
+	case errors.Is(err, errForbidden):
+		logf.FromContext(req.Context()).Info("incident API request denied", "Audit", true, "User", user, "Verb", verb)
+		http.Error(w, fmt.Sprintf("user %s may not %s emergencyblocks", user, verb), http.StatusForbidden)
+		return "", false
+	case err != nil:
+		logf.FromContext(req.Context()).Error(err, "unable to authorize incident API request")
+		http.Error(w, "unable to authorize request", http.StatusInternalServerError)
+		return "", false
+	}
+
+	return user, true
+}
+
+// listBlocks responds with the EmergencyBlocks.
+func (a *IncidentAPI) listBlocks(w http.ResponseWriter, req *http.Request, _ string) {
+	blockList := ingressnetworkpoliciesv1.EmergencyBlockList{}
+	if err := a.Client.List(req.Context(), &blockList); err != nil {
+		logf.FromContext(req.Context()).Error(err, "unable to list EmergencyBlocks")
+		http.Error(w, "unable to list blocks", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, blockList.Items)
+}
+
+// addBlock creates the EmergencyBlock of the request, or renews the block of the range.
+// Renewing requires the update verb too, and never shortens the block, so blocks can't be lifted by creating them again.
+func (a *IncidentAPI) addBlock(w http.ResponseWriter, req *http.Request, user string) {
+	ctx := req.Context()
+	log := logf.FromContext(ctx)
+
+	var request EmergencyBlockRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, req.Body, 64<<10)).Decode(&request); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	prefix, err := a.parseBlockPrefix(request.CIDR)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(request.Reason) == "" {
+		http.Error(w, "reason is required", http.StatusBadRequest)
+		return
+	}
+	ttl, err := a.blockTTL(request.TTL)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	block := &ingressnetworkpoliciesv1.EmergencyBlock{}
+	status := http.StatusOK
+	if err := a.Client.Get(ctx, client.ObjectKey{Name: emergencyBlockName(prefix)}, block); err != nil {
+		if !apierrors.IsNotFound(err) {
+			log.Error(err, "unable to fetch EmergencyBlock", "CIDR", prefix.String())
+			http.Error(w, "unable to fetch block", http.StatusInternalServerError)
+			return
+		}
+		block.Name = emergencyBlockName(prefix)
+		status = http.StatusCreated
+	}
+
+	expires := metav1.NewTime(time.Now().Add(ttl).Truncate(time.Second))
+	if status == http.StatusOK {
+		if _, ok := a.authorize(w, req, "update"); !ok {
+			return
+		}
+		if expires.Before(&block.Spec.Expires) {
+			expires = block.Spec.Expires
+		}
+	}
+
+	block.Spec = ingressnetworkpoliciesv1.EmergencyBlockSpec{
+		CIDR:        prefix.String(),
+		Reason:      strings.TrimSpace(request.Reason),
+		Expires:     expires,
+		RequestedBy: user,
+	}
+
+	if status == http.StatusCreated {
+		err = a.Client.Create(ctx, block)
+	} else {
+		err = a.Client.Update(ctx, block)
+	}
+	if err != nil {
+		log.Error(err, "unable to store EmergencyBlock", "CIDR", prefix.String())
+		http.Error(w, "unable to store block", http.StatusInternalServerError)
+		return
+	}
+
+	log.Info("Emergency block added", "Audit", true, "User", user, "CIDR", block.Spec.CIDR, "Expires", block.Spec.Expires, "Reason", block.Spec.Reason)
+	if a.Recorder != nil {
+		a.Recorder.Eventf(block, corev1.EventTypeWarning, EventReasonEmergencyBlockAdded, "%s blocked %s until %s: %s",
+			user, block.Spec.CIDR, block.Spec.Expires.UTC().Format(time.RFC3339), block.Spec.Reason)
+	}
+
+	writeJSON(w, status, block)
+}
+
+// removeBlock deletes the EmergencyBlock of the range given in the cidr query parameter.
+func (a *IncidentAPI) removeBlock(w http.ResponseWriter, req *http.Request, user string) {
+	ctx := req.Context()
+	log := logf.FromContext(ctx)
+
+	prefix, err := a.parseBlockPrefix(req.URL.Query().Get("cidr"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	block := &ingressnetworkpoliciesv1.EmergencyBlock{}
+	if err := a.Client.Get(ctx, client.ObjectKey{Name: emergencyBlockName(prefix)}, block); err != nil {
+		if apierrors.IsNotFound(err) {
+			http.Error(w, fmt.Sprintf("%s is not blocked", prefix), http.StatusNotFound)
+			return
+		}
+		log.Error(err, "unable to fetch EmergencyBlock", "CIDR", prefix.String())
+		http.Error(w, "unable to fetch block", http.StatusInternalServerError)
+		return
+	}
+
+	if err := a.Client.Delete(ctx, block); client.IgnoreNotFound(err) != nil {
+		log.Error(err, "unable to delete EmergencyBlock", "CIDR", prefix.String())
+		http.Error(w, "unable to delete block", http.StatusInternalServerError)
+		return
+	}
+
+	log.Info("Emergency block removed", "Audit", true, "User", user, "CIDR", block.Spec.CIDR, "Reason", block.Spec.Reason)
+	if a.Recorder != nil {
+		a.Recorder.Eventf(block, corev1.EventTypeNormal, EventReasonEmergencyBlockRemoved, "%s removed the block of %s", user, block.Spec.CIDR)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// blockTTL parses the TTL of a request, applying the default and maximum TTL.
+func (a *IncidentAPI) blockTTL(value string) (time.Duration, error) {
+	ttl := a.DefaultTTL
+	if value = strings.TrimSpace(value); value != "" {
+		var err error
+		if ttl, err = time.ParseDuration(value); err != nil {
+			return 0, fmt.Errorf("invalid ttl %q, expected f.ex 30m", value)
+		}
+	}
+	if ttl <= 0 {
+		return 0, fmt.Errorf("ttl must be positive")
+	}
+	if a.MaxTTL > 0 && ttl > a.MaxTTL {
+		return 0, fmt.Errorf("ttl %s exceeds the maximum of %s", ttl, a.MaxTTL)
+	}
+	return ttl, nil
+}
+
+// parseBlockPrefix parses an address or range to block, masking host bits.
+// Ranges broader than the minimum prefix length of their family are refused.
+func (a *IncidentAPI) parseBlockPrefix(value string) (netip.Prefix, error) {
+	cidr, ok := normalizePrefix(value)
+	if !ok {
+		return netip.Prefix{}, fmt.Errorf("invalid cidr %q, expected f.ex 203.0.113.7 or 203.0.113.0/24", value)
+	}
+	prefix, err := netip.ParsePrefix(cidr)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("invalid cidr %q: %w", value, err)
+	}
+
+	minPrefixLength := a.MinPrefixLengthIPv6
+	if prefix.Addr().Is4() {
+		minPrefixLength = a.MinPrefixLengthIPv4
+	}
+	if prefix.Bits() < minPrefixLength {
+		return netip.Prefix{}, fmt.Errorf("cidr %q is broader than /%d", value, minPrefixLength)
+	}
+
+	return prefix.Masked(), nil
+}
+
+// emergencyBlockName returns the name of the EmergencyBlock of the range, f.ex block-203-0-113-7-32.
+func emergencyBlockName(prefix netip.Prefix) string {
+	return "block-" + strings.NewReplacer(".", "-", ":", "-", "/", "-").Replace(prefix.String())
+}
+
+// writeJSON writes the value as the JSON response.
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(value)
+}
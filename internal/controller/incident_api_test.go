@@ -0,0 +1,205 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	ingressnetworkpoliciesv1 "github.com/vitistack/ingressnetworkpolicy-operator/api/v1"
+)
+
+// fakeIncidentAuthorizer authorizes the tokens of its users for the listed verbs.
+type fakeIncidentAuthorizer map[string][]string
+
+func (f fakeIncidentAuthorizer) Authorize(_ context.Context, token string, verb string) (string, error) {
+	verbs, found := f[token]
+	if !found {
+		return "", errUnauthenticated
+	}
+	for _, allowed := range verbs {
+		if allowed == verb {
+			return token, nil
+		}
+	}
+	return token, errForbidden
+}
+
+var _ = Describe("Incident API", func() {
+	ctx := context.Background()
+
+	var server *httptest.Server
+	var recorder *record.FakeRecorder
+
+	request := func(method string, path string, token string, body any) *http.Response {
+		var content []byte
+		if body != nil {
+			content, _ = json.Marshal(body)
+		}
+		req, err := http.NewRequest(method, server.URL+path, bytes.NewReader(content))
+		Expect(err).NotTo(HaveOccurred())
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := server.Client().Do(req)
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(resp.Body.Close)
+		return resp
+	}
+
+	BeforeEach(func() {
+		ensureNamespace(ctx, "default")
+		recorder = record.NewFakeRecorder(10)
+		api := &IncidentAPI{
+			Client: k8sClient,
+			Authorizer: fakeIncidentAuthorizer{
+				"oncall":   {"list", "create", "update", "delete"},
+				"reporter": {"create"},
+				"auditor":  {"list"},
+			},
+			Recorder:   recorder,
+			DefaultTTL: time.Hour,
+			MaxTTL:     24 * time.Hour,
+
+			MinPrefixLengthIPv4: 16,
+			MinPrefixLengthIPv6: 32,
+		}
+		server = httptest.NewServer(api.Handler())
+		DeferCleanup(server.Close)
+	})
+
+	It("should authenticate and authorize requests", func() {
+		Expect(request(http.MethodGet, "/v1/blocks", "", nil).StatusCode).To(Equal(http.StatusUnauthorized))
+		Expect(request(http.MethodGet, "/v1/blocks", "stranger", nil).StatusCode).To(Equal(http.StatusUnauthorized))
+		Expect(request(http.MethodGet, "/v1/blocks", "auditor", nil).StatusCode).To(Equal(http.StatusOK))
+		Expect(request(http.MethodPost, "/v1/blocks", "auditor", EmergencyBlockRequest{CIDR: "203.0.113.7", Reason: "attack"}).StatusCode).
+			To(Equal(http.StatusForbidden))
+	})
+
+	It("should validate the requested blocks", func() {
+		Expect(request(http.MethodPost, "/v1/blocks", "oncall", EmergencyBlockRequest{CIDR: "not-an-ip", Reason: "attack"}).StatusCode).
+			To(Equal(http.StatusBadRequest))
+		Expect(request(http.MethodPost, "/v1/blocks", "oncall", EmergencyBlockRequest{CIDR: "203.0.113.7"}).StatusCode).
+			To(Equal(http.StatusBadRequest))
+		Expect(request(http.MethodPost, "/v1/blocks", "oncall", EmergencyBlockRequest{CIDR: "203.0.113.7", TTL: "48h", Reason: "attack"}).StatusCode).
+			To(Equal(http.StatusBadRequest))
+		Expect(request(http.MethodDelete, "/v1/blocks?cidr=203.0.113.7", "oncall", nil).StatusCode).To(Equal(http.StatusNotFound))
+
+		// Ranges broader than the minimum prefix length are refused
+		for _, cidr := range []string{"0.0.0.0/0", "10.0.0.0/8", "::/0", "2001:db8::/16"} {
+			resp := request(http.MethodPost, "/v1/blocks", "oncall", EmergencyBlockRequest{CIDR: cidr, Reason: "attack"})
+			Expect(resp.StatusCode).To(Equal(http.StatusBadRequest), cidr)
+		}
+	})
+
+	It("should refuse to serve without TLS unless allowed", func() {
+		api := &IncidentAPI{Client: k8sClient, Authorizer: fakeIncidentAuthorizer{}, BindAddress: "127.0.0.1:0"}
+		Expect(api.Start(ctx)).To(MatchError(ContainSubstring("requires TLS")))
+
+		api.Insecure = true
+		serveCtx, cancel := context.WithCancel(ctx)
+		cancel()
+		Expect(api.Start(serveCtx)).To(Succeed())
+	})
+
+	It("should block a range on every managed Ingress until it is removed", func() {
+		ingress := newTestIngress("incident-app", map[string]string{AnnotationWhitelist: "10.95.0.0/16"})
+		Expect(k8sClient.Create(ctx, ingress)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, ingress)).To(Succeed()) }()
+
+		resp := request(http.MethodPost, "/v1/blocks", "oncall", EmergencyBlockRequest{CIDR: "203.0.113.9", TTL: "30m", Reason: "credential stuffing"})
+		Expect(resp.StatusCode).To(Equal(http.StatusCreated))
+		var block ingressnetworkpoliciesv1.EmergencyBlock
+		Expect(json.NewDecoder(resp.Body).Decode(&block)).To(Succeed())
+		Expect(block.Name).To(Equal("block-203-0-113-9-32"))
+		Expect(block.Spec.CIDR).To(Equal("203.0.113.9/32"))
+		Expect(block.Spec.RequestedBy).To(Equal("oncall"))
+		Expect(block.Spec.Expires.Time).To(BeTemporally("~", time.Now().Add(30*time.Minute), time.Minute))
+		Expect(recorder.Events).To(Receive(ContainSubstring(EventReasonEmergencyBlockAdded)))
+
+		// Adding the range again renews the block, but only for users who may update blocks
+		Expect(request(http.MethodPost, "/v1/blocks", "reporter", EmergencyBlockRequest{CIDR: "203.0.113.9", TTL: "1s", Reason: "lifted"}).StatusCode).
+			To(Equal(http.StatusForbidden))
+		Expect(request(http.MethodPost, "/v1/blocks", "oncall", EmergencyBlockRequest{CIDR: "203.0.113.9/32", Reason: "still attacking"}).StatusCode).
+			To(Equal(http.StatusOK))
+		Expect(recorder.Events).To(Receive(ContainSubstring("still attacking")))
+
+		// Renewing never shortens the block
+		resp = request(http.MethodPost, "/v1/blocks", "oncall", EmergencyBlockRequest{CIDR: "203.0.113.9", TTL: "1s", Reason: "shorter"})
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		Expect(json.NewDecoder(resp.Body).Decode(&block)).To(Succeed())
+		Expect(block.Spec.Expires.Time).To(BeTemporally("~", time.Now().Add(time.Hour), time.Minute))
+		Expect(recorder.Events).To(Receive(ContainSubstring("shorter")))
+
+		ingressKey := types.NamespacedName{Name: ingress.Name, Namespace: ingress.Namespace}
+		ingressReconciler := &IngressReconciler{Client: k8sClient, Scheme: k8sClient.Scheme(), Access: AccessConfig{Recorder: recorder}}
+		Expect(ingressReconciler.ingressesWithAccess(ctx, &block)).To(ContainElement(reconcile.Request{NamespacedName: ingressKey}))
+		result, err := ingressReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: ingressKey})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(BeNumerically("~", time.Hour, time.Minute))
+
+		updated := &networkingv1.Ingress{}
+		Expect(k8sClient.Get(ctx, ingressKey, updated)).To(Succeed())
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxDenylist, "203.0.113.9/32"))
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationEmergencyBlocks, "203.0.113.9/32"))
+		Expect(recorder.Events).To(Receive(ContainSubstring("Emergency block applied for 203.0.113.9/32")))
+
+		Expect(request(http.MethodDelete, "/v1/blocks?cidr=203.0.113.9", "oncall", nil).StatusCode).To(Equal(http.StatusNoContent))
+		Expect(recorder.Events).To(Receive(ContainSubstring(EventReasonEmergencyBlockRemoved)))
+
+		_, err = ingressReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: ingressKey})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(k8sClient.Get(ctx, ingressKey, updated)).To(Succeed())
+		Expect(updated.Annotations).NotTo(HaveKey(AnnotationNginxDenylist))
+		Expect(updated.Annotations).NotTo(HaveKey(AnnotationEmergencyBlocks))
+	})
+
+	It("should delete expired blocks", func() {
+		block := &ingressnetworkpoliciesv1.EmergencyBlock{
+			ObjectMeta: metav1.ObjectMeta{Name: "block-198-51-100-0-24"},
+			Spec: ingressnetworkpoliciesv1.EmergencyBlockSpec{
+				CIDR:    "198.51.100.0/24",
+				Reason:  "scanner",
+				Expires: metav1.NewTime(time.Now().Add(time.Hour)),
+			},
+		}
+		Expect(k8sClient.Create(ctx, block)).To(Succeed())
+
+		blockReconciler := &EmergencyBlockReconciler{Client: k8sClient, Scheme: k8sClient.Scheme(), Recorder: recorder}
+		result, err := blockReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(block)})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(BeNumerically("~", time.Hour, time.Minute))
+
+		block.Spec.Expires = metav1.NewTime(time.Now().Add(-time.Second))
+		Expect(k8sClient.Update(ctx, block)).To(Succeed())
+		_, err = blockReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(block)})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(recorder.Events).To(Receive(ContainSubstring(EventReasonEmergencyBlockExpired)))
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(block), block)).NotTo(Succeed())
+	})
+})
@@ -19,26 +19,52 @@ package controller
 import (
 	"context"
 	"reflect"
-	"strings"
+	"slices"
 
+	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	ingressnetworkpoliciesv1 "github.com/vitistack/ingressnetworkpolicy-operator/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
+// ingressOwnAccessAnnotations are the Ingress annotations requesting access lists of its own.
+var ingressOwnAccessAnnotations = []string{
+	AnnotationWhiteListNetworkPolicy,
+	AnnotationDenyListNetworkPolicy,
+	AnnotationWhiteListPolicySelector,
+	AnnotationDenyListPolicySelector,
+	AnnotationWhitelist,
+	AnnotationDenylist,
+	AnnotationInheritBackendPolicies,
+	AnnotationLockdown,
+}
+
+// ingressAccessAnnotations are the Ingress annotations the computed access lists depend on.
+var ingressAccessAnnotations = append([]string{AnnotationPolicyPorts, AnnotationNamespaceDefaults, AnnotationWhitelistCombination}, ingressOwnAccessAnnotations...)
+
 // IngressReconciler reconciles a Ingress object
 type IngressReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
+	Access AccessConfig
 }
 
 // +kubebuilder:rbac:groups="networking.k8s.io",resources=ingresses,verbs=get;list;watch;create;update
 // +kubebuilder:rbac:groups="networking.k8s.io",resources=ingresses/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups="networking.k8s.io",resources=ingresses/finalizers,verbs=update
+// +kubebuilder:rbac:groups="networking.k8s.io",resources=ingressclasses,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -61,61 +87,13 @@ func (r *IngressReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
-	// Get Annotations from Ingress
-	AnnotationWhiteListNetworkPolicy := ingress.GetAnnotations()[AnnotationWhiteListNetworkPolicy]
-	AnnotationDenyListNetworkPolicy := ingress.GetAnnotations()[AnnotationDenyListNetworkPolicy]
-	annotationWhitelist := ingress.GetAnnotations()[AnnotationWhitelist]
-	AnnotationDenylist := ingress.GetAnnotations()[AnnotationDenylist]
-
-	// Create slices from annotations
-	sliceWhitelistNetworkPolicy := filterSliceFromString(strings.Split(AnnotationWhiteListNetworkPolicy, ","))
-	sliceDenyListNetworkPolicy := filterSliceFromString(strings.Split(AnnotationDenyListNetworkPolicy, ","))
-	sliceWhitelist := filterSliceFromString(strings.Split(annotationWhitelist, ","))
-	sliceDenylist := filterSliceFromString(strings.Split(AnnotationDenylist, ","))
-
-	// Create CIDR Lists
-	var cidrWhitelist []string
-	var cidrDenylist []string
-
-	if len(sliceWhitelistNetworkPolicy) > 0 || len(sliceWhitelist) > 0 {
-		cidrWhitelist = createCidrList(ctx, r, ingress, sliceWhitelistNetworkPolicy, sliceWhitelist)
-	}
-
-	if len(sliceDenyListNetworkPolicy) > 0 || len(sliceDenylist) > 0 {
-		cidrDenylist = createCidrList(ctx, r, ingress, sliceDenyListNetworkPolicy, sliceDenylist)
-	}
-
-	// Update Ingress Annotations
-
-	if len(cidrWhitelist) > 0 {
-		ingress.Annotations[AnnotationNginxWhitelist] = strings.Join(cidrWhitelist, ",")
-	} else {
-		delete(ingress.Annotations, AnnotationNginxWhitelist)
-	}
-
-	if len(cidrDenylist) > 0 {
-		ingress.Annotations[AnnotationNginxDenylist] = strings.Join(cidrDenylist, ",")
-	} else {
-		delete(ingress.Annotations, AnnotationNginxDenylist)
-	}
-
-	// Validate annotations before updating
-	err := validateAnnotations(ingress.Annotations)
+	// Compute and render the access lists for the Ingress
+	requeueAfter, err := updateIngressAccess(ctx, r.Client, r.Scheme, r.Access, &ingress)
 	if err != nil {
-		log.Error(err, "invalid annotations for Ingress", "Ingress.Name", ingress.Name)
-		return ctrl.Result{}, err
-	}
-
-	// Update Ingress
-	if err := r.Update(ctx, &ingress); err != nil {
-		log.Error(err, "unable to remove Ingress annotation", "Ingress.Name", ingress.Name)
 		return ctrl.Result{}, err
 	}
 
-	// Log successful update
-	log.Info("Updated Ingress annotation", "Ingress.Name", ingress.Name)
-
-	return ctrl.Result{}, nil
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
 }
 
 // SetupWithManager sets up the controller with the Manager.
@@ -125,27 +103,55 @@ func (r *IngressReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	annotationChangedPredicate := predicate.Funcs{
 		UpdateFunc: func(e event.UpdateEvent) bool {
 
-			oldObjAnnotationWhiteListNetworkPolicy := e.ObjectOld.GetAnnotations()[AnnotationWhiteListNetworkPolicy]
-			newObjAnnotationWhiteListNetworkPolicy := e.ObjectNew.GetAnnotations()[AnnotationWhiteListNetworkPolicy]
-			oldObjAnnotationDenyListNetworkPolicy := e.ObjectOld.GetAnnotations()[AnnotationDenyListNetworkPolicy]
-			newObjAnnotationDenyListNetworkPolicy := e.ObjectNew.GetAnnotations()[AnnotationDenyListNetworkPolicy]
-			oldObjAnnotationWhitelist := e.ObjectOld.GetAnnotations()[AnnotationWhitelist]
-			newObjAnnotationWhitelist := e.ObjectNew.GetAnnotations()[AnnotationWhitelist]
-			oldObjAnnotationDenylist := e.ObjectOld.GetAnnotations()[AnnotationDenylist]
-			newObjAnnotationDenylist := e.ObjectNew.GetAnnotations()[AnnotationDenylist]
-
 			// Trigger reconciliation if relevant annotations have changed
-			return !reflect.DeepEqual(oldObjAnnotationWhiteListNetworkPolicy, newObjAnnotationWhiteListNetworkPolicy) ||
-				!reflect.DeepEqual(oldObjAnnotationWhitelist, newObjAnnotationWhitelist) ||
-				!reflect.DeepEqual(oldObjAnnotationDenyListNetworkPolicy, newObjAnnotationDenyListNetworkPolicy) ||
-				!reflect.DeepEqual(oldObjAnnotationDenylist, newObjAnnotationDenylist)
+			for _, annotation := range ingressAccessAnnotations {
+				oldObjAnnotation := e.ObjectOld.GetAnnotations()[annotation]
+				newObjAnnotation := e.ObjectNew.GetAnnotations()[annotation]
+				if !reflect.DeepEqual(oldObjAnnotation, newObjAnnotation) {
+					return true
+				}
+			}
+
+			oldIngress, oldOk := e.ObjectOld.(*v1.Ingress)
+			newIngress, newOk := e.ObjectNew.(*v1.Ingress)
+			if !oldOk || !newOk {
+				return false
+			}
+
+			// Trigger reconciliation if a managed Ingress moved to another IngressClass, which may select another renderer
+			if ingressClassName(oldIngress) != ingressClassName(newIngress) && ingressManaged(newIngress) {
+				return true
+			}
+
+			// Trigger reconciliation if cert-manager added or removed a challenge path on a managed Ingress
+			if ingressHasACMEChallenge(oldIngress) != ingressHasACMEChallenge(newIngress) && ingressManaged(newIngress) {
+				return true
+			}
+
+			// Trigger reconciliation if a canary inheriting access lists, or one of its hosts and paths, changed
+			if !ingressIsCanary(oldIngress) && !ingressIsCanary(newIngress) {
+				return false
+			}
+			if ingressIsCanary(oldIngress) == ingressIsCanary(newIngress) && slices.Equal(ingressRoutes(oldIngress), ingressRoutes(newIngress)) {
+				return false
+			}
+			return canaryInherits(context.Background(), r.Client, newIngress)
 		},
 		CreateFunc: func(e event.CreateEvent) bool {
-			// Trigger reconciliation if relevant annotations are present
-			return e.Object.GetAnnotations()[AnnotationWhiteListNetworkPolicy] != "" ||
-				e.Object.GetAnnotations()[AnnotationWhitelist] != "" ||
-				e.Object.GetAnnotations()[AnnotationDenyListNetworkPolicy] != "" ||
-				e.Object.GetAnnotations()[AnnotationDenylist] != ""
+			// Trigger reconciliation if relevant annotations are present, the Ingress is a canary of a managed Ingress,
+			// a cert-manager solver, the namespace has default references or is locked down
+			if ingressHasAccessAnnotations(e.Object) || ingressIsACMESolver(e.Object) {
+				return true
+			}
+			if ingress, ok := e.Object.(*v1.Ingress); ok && ingressIsCanary(ingress) && canaryInherits(context.Background(), r.Client, ingress) {
+				return true
+			}
+			annotations, err := getNamespaceAnnotations(context.Background(), r.Client, e.Object.GetNamespace())
+			if err != nil || namespaceHasDefaults(annotations) {
+				return true
+			}
+			lockedDown, err := namespaceLockedDown(context.Background(), r.Client, e.Object.GetNamespace())
+			return err != nil || lockedDown
 		},
 		DeleteFunc: func(e event.DeleteEvent) bool {
 			// No reconciliation on delete
@@ -153,9 +159,282 @@ func (r *IngressReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		},
 	}
 
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&v1.Ingress{}).
+	controllerBuilder := ctrl.NewControllerManagedBy(mgr).
+		For(&v1.Ingress{}, builder.WithPredicates(annotationChangedPredicate)).
+		WatchesMetadata(&corev1.ConfigMap{}, handler.EnqueueRequestsFromMapFunc(r.ingressesForSource(SourceConfigMap))).
+		WatchesMetadata(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.ingressesForSource(SourceSecret))).
+		Watches(&ingressnetworkpoliciesv1.CIDRFeed{}, handler.EnqueueRequestsFromMapFunc(r.ingressesForSource(SourceFeed))).
+		Watches(&ingressnetworkpoliciesv1.NetBoxPrefixSource{}, handler.EnqueueRequestsFromMapFunc(r.ingressesForSource(SourceNetBox))).
+		Watches(&ingressnetworkpoliciesv1.CIDRSet{}, handler.EnqueueRequestsFromMapFunc(r.ingressesForSource(SourceCIDRSet))).
+		Watches(&ingressnetworkpoliciesv1.AccessSchedule{}, handler.EnqueueRequestsFromMapFunc(r.ingressesUsingSchedule)).
+		Watches(&ingressnetworkpoliciesv1.AccessGuardrail{}, handler.EnqueueRequestsFromMapFunc(r.ingressesWithAccess)).
+		Watches(&ingressnetworkpoliciesv1.MandatoryDenylist{}, handler.EnqueueRequestsFromMapFunc(r.ingressesWithAccess)).
+		Watches(&ingressnetworkpoliciesv1.EmergencyBlock{}, handler.EnqueueRequestsFromMapFunc(r.ingressesWithAccess)).
+		Watches(&ingressnetworkpoliciesv1.LockdownPolicy{}, handler.EnqueueRequestsFromMapFunc(r.ingressesForLockdown)).
+		Watches(&v1.Ingress{}, handler.EnqueueRequestsFromMapFunc(r.canariesOfIngress)).
+		Watches(&v1.IngressClass{},
+			handler.EnqueueRequestsFromMapFunc(r.ingressesOfClass),
+			builder.WithPredicates(predicate.AnnotationChangedPredicate{})).
+		WatchesMetadata(&corev1.Namespace{},
+			handler.EnqueueRequestsFromMapFunc(r.ingressesInNamespace),
+			builder.WithPredicates(predicate.Or[client.Object](predicate.LabelChangedPredicate{}, predicate.AnnotationChangedPredicate{}))).
+		Watches(&corev1.Node{},
+			handler.EnqueueRequestsFromMapFunc(r.ingressesSelectingSource(SourceNodeExternalIP, SourceNodeInternalIP, SourceNodePodCIDR)),
+			builder.WithPredicates(nodeSourceChangedPredicate)).
+		Watches(&corev1.Service{},
+			handler.EnqueueRequestsFromMapFunc(r.ingressesForService),
+			builder.WithPredicates(serviceSourceChangedPredicate)).
+		WatchesMetadata(&corev1.Pod{},
+			handler.EnqueueRequestsFromMapFunc(r.ingressesInheritingFromPod),
+			builder.WithPredicates(predicate.LabelChangedPredicate{}))
+
+	// Watch the CIDR sets of the CNIs installed in the cluster, CNIs installed later require a restart
+	for _, source := range networkSetSources {
+		gvk, installed := source.installedVersion(mgr.GetRESTMapper())
+		if !installed {
+			continue
+		}
+		object := &metav1.PartialObjectMetadata{}
+		object.SetGroupVersionKind(gvk)
+		controllerBuilder = controllerBuilder.WatchesMetadata(object, handler.EnqueueRequestsFromMapFunc(r.ingressesForSource(source.kind)))
+	}
+
+	// Recompute Ingresses with geo: entries when the GeoIP database is reloaded
+	if r.Access.GeoIP != nil {
+		controllerBuilder = controllerBuilder.WatchesRawSource(
+			source.Channel(r.Access.GeoIP.Changes(), handler.EnqueueRequestsFromMapFunc(r.ingressesUsingGeoIP)))
+	}
+
+	return controllerBuilder.
 		Named("ingress").
-		WithEventFilter(annotationChangedPredicate).
 		Complete(r)
 }
+
+// ingressesForSource maps a changed source object of the given kind to the Ingresses referencing it,
+// or to every managed Ingress when a MandatoryDenylist or LockdownPolicy references it.
+func (r *IngressReconciler) ingressesForSource(kind string) handler.MapFunc {
+	return func(ctx context.Context, obj client.Object) []reconcile.Request {
+		log := logf.FromContext(ctx)
+
+		if clusterPoliciesUse(ctx, r.Client, func(annotations map[string]string) bool {
+			return ingressReferencesSource(annotations, kind, obj.GetNamespace(), obj.GetName())
+		}) {
+			return r.ingressesWithAccess(ctx, obj)
+		}
+
+		ingressList := v1.IngressList{}
+		if err := r.List(ctx, &ingressList); err != nil {
+			log.Error(err, "unable to list Ingress")
+			return nil
+		}
+
+		var requests []reconcile.Request
+		for _, ingress := range ingressList.Items {
+			if ingressReferencesSource(ingress.GetAnnotations(), kind, obj.GetNamespace(), obj.GetName()) {
+				log.Info("Matched Ingress found for source", "Ingress.Name", ingress.Name, "Source.Kind", kind, "Source.Name", obj.GetName())
+				requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&ingress)})
+			}
+		}
+
+		return requests
+	}
+}
+
+// ingressesSelectingSource maps a changed Node or Service to the Ingresses with a reference of one of the kinds selecting it.
+// Updates are mapped for both the old and new object, so objects leaving the selection update the Ingress too.
+// Objects selected by a MandatoryDenylist or LockdownPolicy are mapped to every managed Ingress.
+func (r *IngressReconciler) ingressesSelectingSource(kinds ...string) handler.MapFunc {
+	return func(ctx context.Context, obj client.Object) []reconcile.Request {
+		log := logf.FromContext(ctx)
+
+		if clusterPoliciesUse(ctx, r.Client, func(annotations map[string]string) bool {
+			return slices.ContainsFunc(kinds, func(kind string) bool {
+				return ingressSelectsSourceObject(annotations, kind, obj)
+			})
+		}) {
+			return r.ingressesWithAccess(ctx, obj)
+		}
+
+		ingressList := v1.IngressList{}
+		if err := r.List(ctx, &ingressList); err != nil {
+			log.Error(err, "unable to list Ingress")
+			return nil
+		}
+
+		var requests []reconcile.Request
+		for _, ingress := range ingressList.Items {
+			for _, kind := range kinds {
+				if ingressSelectsSourceObject(ingress.GetAnnotations(), kind, obj) {
+					log.Info("Matched Ingress found for source", "Ingress.Name", ingress.Name, "Source.Kind", kind, "Source.Name", obj.GetName())
+					requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&ingress)})
+					break
+				}
+			}
+		}
+
+		return requests
+	}
+}
+
+// ingressesForService maps a changed Service to the Ingresses selecting its load balancer addresses,
+// and to the Ingresses inheriting from the NetworkPolicies selecting its pods.
+func (r *IngressReconciler) ingressesForService(ctx context.Context, obj client.Object) []reconcile.Request {
+	return append(r.ingressesSelectingSource(SourceServiceLoadBalancer)(ctx, obj), r.ingressesInheritingFromService(ctx, obj)...)
+}
+
+// nodeSourceChangedPredicate filters Node updates to changes of labels, addresses and pod CIDRs,
+// ignoring the frequent status heartbeats.
+var nodeSourceChangedPredicate = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		oldNode, oldOk := e.ObjectOld.(*corev1.Node)
+		newNode, newOk := e.ObjectNew.(*corev1.Node)
+		if !oldOk || !newOk {
+			return true
+		}
+		return !reflect.DeepEqual(oldNode.Labels, newNode.Labels) ||
+			!reflect.DeepEqual(oldNode.Status.Addresses, newNode.Status.Addresses) ||
+			!reflect.DeepEqual(oldNode.Spec.PodCIDRs, newNode.Spec.PodCIDRs)
+	},
+}
+
+// serviceSourceChangedPredicate filters Service updates to changes of labels and load balancer addresses,
+// and of the selector and ports backend NetworkPolicies are inherited through.
+var serviceSourceChangedPredicate = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		oldService, oldOk := e.ObjectOld.(*corev1.Service)
+		newService, newOk := e.ObjectNew.(*corev1.Service)
+		if !oldOk || !newOk {
+			return true
+		}
+		return !reflect.DeepEqual(oldService.Labels, newService.Labels) ||
+			!reflect.DeepEqual(oldService.Status.LoadBalancer, newService.Status.LoadBalancer) ||
+			!reflect.DeepEqual(oldService.Spec.Selector, newService.Spec.Selector) ||
+			!reflect.DeepEqual(oldService.Spec.Ports, newService.Spec.Ports)
+	},
+}
+
+// ingressesUsingGeoIP maps a reload of the GeoIP database to the Ingresses with geo: entries,
+// or to every managed Ingress when a MandatoryDenylist or LockdownPolicy has geo: entries.
+func (r *IngressReconciler) ingressesUsingGeoIP(ctx context.Context, obj client.Object) []reconcile.Request {
+	log := logf.FromContext(ctx)
+
+	if clusterPoliciesUse(ctx, r.Client, ingressUsesGeoIP) {
+		return r.ingressesWithAccess(ctx, obj)
+	}
+
+	ingressList := v1.IngressList{}
+	if err := r.List(ctx, &ingressList); err != nil {
+		log.Error(err, "unable to list Ingress")
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, ingress := range ingressList.Items {
+		if ingressUsesGeoIP(ingress.Annotations) {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&ingress)})
+		}
+	}
+
+	return requests
+}
+
+// ingressesUsingSchedule maps a changed AccessSchedule to the Ingresses with references or entries limited to it,
+// or to every managed Ingress when a MandatoryDenylist or LockdownPolicy is limited to it.
+func (r *IngressReconciler) ingressesUsingSchedule(ctx context.Context, obj client.Object) []reconcile.Request {
+	log := logf.FromContext(ctx)
+
+	if clusterPoliciesUse(ctx, r.Client, func(annotations map[string]string) bool {
+		return ingressUsesSchedule(ctx, r.Client, annotations, obj.GetNamespace(), obj.GetName())
+	}) {
+		return r.ingressesWithAccess(ctx, obj)
+	}
+
+	ingressList := v1.IngressList{}
+	if err := r.List(ctx, &ingressList); err != nil {
+		log.Error(err, "unable to list Ingress")
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, ingress := range ingressList.Items {
+		if ingressUsesSchedule(ctx, r.Client, ingress.Annotations, obj.GetNamespace(), obj.GetName()) {
+			log.Info("Matched Ingress found for schedule", "Ingress.Name", ingress.Name, "Schedule.Name", obj.GetName())
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&ingress)})
+		}
+	}
+
+	return requests
+}
+
+// ingressesWithAccess maps a change affecting every Ingress, like a changed AccessGuardrail, MandatoryDenylist or EmergencyBlock,
+// to the Ingresses managed by the operator or locked down.
+func (r *IngressReconciler) ingressesWithAccess(ctx context.Context, _ client.Object) []reconcile.Request {
+	return r.ingressesMatching(ctx, func(ingress client.Object) bool {
+		return ingressManaged(ingress) || ingressLockedDown(ingress)
+	})
+}
+
+// ingressesForLockdown maps a changed LockdownPolicy to the Ingresses managed by the operator or locked down,
+// and to every Ingress while the policy is active, since namespace and cluster lockdowns apply to every Ingress.
+func (r *IngressReconciler) ingressesForLockdown(ctx context.Context, obj client.Object) []reconcile.Request {
+	if policy, ok := obj.(*ingressnetworkpoliciesv1.LockdownPolicy); ok && policy.Spec.Active {
+		return r.ingressesMatching(ctx, func(client.Object) bool { return true })
+	}
+	return r.ingressesWithAccess(ctx, obj)
+}
+
+// ingressesOfClass maps a changed IngressClass, f.ex a changed renderer or nginx annotation key,
+// to the Ingresses of the class managed by the operator or locked down.
+func (r *IngressReconciler) ingressesOfClass(ctx context.Context, obj client.Object) []reconcile.Request {
+	return r.ingressesMatching(ctx, func(ingress client.Object) bool {
+		ingressOfClass, ok := ingress.(*v1.Ingress)
+		return ok && ingressClassName(ingressOfClass) == obj.GetName() && (ingressManaged(ingress) || ingressLockedDown(ingress))
+	})
+}
+
+// ingressesInNamespace maps a changed Namespace to the Ingresses managed by the operator or locked down in it,
+// and to every Ingress in it when the namespace has default references or a lockdown annotation.
+func (r *IngressReconciler) ingressesInNamespace(ctx context.Context, obj client.Object) []reconcile.Request {
+	every := namespaceHasDefaults(obj.GetAnnotations()) || obj.GetAnnotations()[AnnotationLockdown] != ""
+	return r.ingressesMatching(ctx, func(ingress client.Object) bool {
+		return every || ingressManaged(ingress) || ingressLockedDown(ingress)
+	}, client.InNamespace(obj.GetName()))
+}
+
+// ingressesMatching lists the Ingresses matching the list options and the match function.
+func (r *IngressReconciler) ingressesMatching(ctx context.Context, match func(client.Object) bool, opts ...client.ListOption) []reconcile.Request {
+	log := logf.FromContext(ctx)
+
+	ingressList := v1.IngressList{}
+	if err := r.List(ctx, &ingressList, opts...); err != nil {
+		log.Error(err, "unable to list Ingress")
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, ingress := range ingressList.Items {
+		if match(&ingress) {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&ingress)})
+		}
+	}
+
+	return requests
+}
+
+// ingressHasAccessAnnotations reports whether one of the annotations the access lists depend on is set.
+func ingressHasAccessAnnotations(obj client.Object) bool {
+	return ingressHasAnyAnnotation(obj, ingressAccessAnnotations...)
+}
+
+// ingressManaged reports whether the operator computes the access lists of the Ingress,
+// requested by its own annotations, by the default references of its namespace, inherited from a primary Ingress,
+// or given by the ACME policy for cert-manager solvers.
+func ingressManaged(obj client.Object) bool {
+	return ingressHasAccessAnnotations(obj) || obj.GetAnnotations()[AnnotationAppliedDefaults] != "" || obj.GetAnnotations()[AnnotationCanaryOf] != "" ||
+		ingressIsACMESolver(obj)
+}
+
+// ingressLockedDown reports whether a lockdown is applied to the Ingress, so lifting it reaches Ingresses not managed by the operator.
+func ingressLockedDown(obj client.Object) bool {
+	return obj.GetAnnotations()[AnnotationAppliedLockdown] != ""
+}
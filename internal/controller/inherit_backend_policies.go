@@ -0,0 +1,204 @@
+package controller
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// +kubebuilder:rbac:groups="",resources=services;pods,verbs=get;list;watch
+
+// inheritBackendCIDRs resolves the backend Services of the Ingress to pods and collects the CIDRs of the
+// NetworkPolicies in the Ingress namespace whose podSelector matches those pods.
+// Only rules allowing a target port of the backend Services are used.
+// It returns the CIDRs together with the names of the NetworkPolicies they were inherited from.
+func inheritBackendCIDRs(ctx context.Context, c client.Client, ingress *v1.Ingress) ([]string, []string, error) {
+	log := logf.FromContext(ctx)
+
+	var pods []metav1.PartialObjectMetadata
+	targetPorts := []intstr.IntOrString{}
+
+	for serviceName, backendPorts := range ingressBackendServices(ingress) {
+		service := corev1.Service{}
+		if err := c.Get(ctx, client.ObjectKey{Namespace: ingress.Namespace, Name: serviceName}, &service); err != nil {
+			if apierrors.IsNotFound(err) {
+				log.Info("backend Service not found for Ingress", "Ingress.Name", ingress.Name, "Service.Name", serviceName)
+				continue
+			}
+			return nil, nil, err
+		}
+
+		if len(service.Spec.Selector) == 0 {
+			continue
+		}
+
+		// Only the labels of the pods are used, listing their metadata shares the informer of the Pod watch
+		podList := metav1.PartialObjectMetadataList{}
+		podList.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("PodList"))
+		if err := c.List(ctx, &podList, client.InNamespace(ingress.Namespace), client.MatchingLabels(service.Spec.Selector)); err != nil {
+			return nil, nil, err
+		}
+
+		pods = append(pods, podList.Items...)
+		targetPorts = append(targetPorts, serviceTargetPorts(&service, backendPorts)...)
+	}
+
+	if len(pods) == 0 {
+		return nil, nil, nil
+	}
+
+	policyList := v1.NetworkPolicyList{}
+	if err := c.List(ctx, &policyList, client.InNamespace(ingress.Namespace)); err != nil {
+		return nil, nil, err
+	}
+
+	var cidrs []string
+	var policyNames []string
+
+	for _, policy := range policyList.Items {
+		selector, err := metav1.LabelSelectorAsSelector(&policy.Spec.PodSelector)
+		if err != nil {
+			log.Error(err, "invalid podSelector in NetworkPolicy", "NetworkPolicy.Name", policy.Name)
+			continue
+		}
+
+		if !selectsAnyPod(selector, pods) {
+			continue
+		}
+
+		policyCIDRs := extractCIDRsFromNetworkPolicy(&policy, nil, targetPorts)
+		if len(policyCIDRs) == 0 {
+			continue
+		}
+
+		log.Info("Inherited CIDRs from backend NetworkPolicy", "Ingress.Name", ingress.Name, "NetworkPolicy.Name", policy.Name, "CIDRs", policyCIDRs)
+		cidrs = append(cidrs, policyCIDRs...)
+		policyNames = append(policyNames, policy.Name)
+	}
+
+	return cidrs, sortSlice(policyNames), nil
+}
+
+// ingressInheritsBackendPolicies reports whether the Ingress inherits CIDRs from the NetworkPolicies selecting its backend pods.
+func ingressInheritsBackendPolicies(obj client.Object) bool {
+	return obj.GetAnnotations()[AnnotationInheritBackendPolicies] == "true"
+}
+
+// ingressesInheritingFromPod maps a changed Pod to the Ingresses in its namespace inheriting from the NetworkPolicies
+// selecting their backend pods, when one of their backend Services selects it, and to the canaries of those Ingresses.
+// Updates are mapped for both the old and new object, so pods leaving a Service update the Ingress too.
+func (r *IngressReconciler) ingressesInheritingFromPod(ctx context.Context, obj client.Object) []reconcile.Request {
+	return r.ingressesInheritingFromBackend(ctx, obj.GetNamespace(), func(ingress *v1.Ingress) bool {
+		for serviceName := range ingressBackendServices(ingress) {
+			service := corev1.Service{}
+			if err := r.Get(ctx, client.ObjectKey{Namespace: ingress.Namespace, Name: serviceName}, &service); err != nil {
+				continue
+			}
+			if len(service.Spec.Selector) > 0 && labels.SelectorFromSet(service.Spec.Selector).Matches(labels.Set(obj.GetLabels())) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// ingressesInheritingFromService maps a changed Service to the Ingresses in its namespace inheriting from the NetworkPolicies
+// selecting their backend pods, when it is one of their backend Services, and to the canaries of those Ingresses.
+func (r *IngressReconciler) ingressesInheritingFromService(ctx context.Context, obj client.Object) []reconcile.Request {
+	return r.ingressesInheritingFromBackend(ctx, obj.GetNamespace(), func(ingress *v1.Ingress) bool {
+		_, backend := ingressBackendServices(ingress)[obj.GetName()]
+		return backend
+	})
+}
+
+// ingressesInheritingFromBackend lists the Ingresses in the namespace inheriting from the NetworkPolicies selecting their
+// backend pods for which isBackend reports true, together with their canaries.
+func (r *IngressReconciler) ingressesInheritingFromBackend(ctx context.Context, namespace string, isBackend func(ingress *v1.Ingress) bool) []reconcile.Request {
+	log := logf.FromContext(ctx)
+
+	ingressList := v1.IngressList{}
+	if err := r.List(ctx, &ingressList, client.InNamespace(namespace)); err != nil {
+		log.Error(err, "unable to list Ingress")
+		return nil
+	}
+
+	inheriting := map[string]bool{}
+	for _, ingress := range ingressList.Items {
+		if ingressInheritsBackendPolicies(&ingress) && isBackend(&ingress) {
+			inheriting[ingress.Name] = true
+		}
+	}
+
+	var requests []reconcile.Request
+	for _, ingress := range ingressList.Items {
+		if inheriting[ingress.Name] || inheriting[ingress.Annotations[AnnotationCanaryOf]] {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&ingress)})
+		}
+	}
+
+	return requests
+}
+
+// ingressBackendServices returns the Services referenced by the Ingress with the ports used on each.
+func ingressBackendServices(ingress *v1.Ingress) map[string][]v1.ServiceBackendPort {
+	services := map[string][]v1.ServiceBackendPort{}
+
+	addBackend := func(backend *v1.IngressBackend) {
+		if backend == nil || backend.Service == nil {
+			return
+		}
+		services[backend.Service.Name] = append(services[backend.Service.Name], backend.Service.Port)
+	}
+
+	addBackend(ingress.Spec.DefaultBackend)
+	for _, rule := range ingress.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for _, path := range rule.HTTP.Paths {
+			addBackend(&path.Backend)
+		}
+	}
+
+	return services
+}
+
+// serviceTargetPorts maps the Ingress backend ports to the target ports of the Service.
+func serviceTargetPorts(service *corev1.Service, backendPorts []v1.ServiceBackendPort) []intstr.IntOrString {
+	var targetPorts []intstr.IntOrString
+
+	for _, backendPort := range backendPorts {
+		for _, servicePort := range service.Spec.Ports {
+			if (backendPort.Name != "" && backendPort.Name != servicePort.Name) ||
+				(backendPort.Name == "" && backendPort.Number != servicePort.Port) {
+				continue
+			}
+
+			targetPort := servicePort.TargetPort
+			if targetPort.Type == intstr.Int && targetPort.IntVal == 0 {
+				targetPort = intstr.FromInt32(servicePort.Port)
+			}
+			targetPorts = append(targetPorts, targetPort)
+		}
+	}
+
+	return targetPorts
+}
+
+// selectsAnyPod reports whether the selector matches at least one of the pods.
+func selectsAnyPod(selector labels.Selector, pods []metav1.PartialObjectMetadata) bool {
+	for _, pod := range pods {
+		if selector.Matches(labels.Set(pod.Labels)) {
+			return true
+		}
+	}
+	return false
+}
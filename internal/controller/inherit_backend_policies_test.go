@@ -0,0 +1,154 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var _ = Describe("Backend NetworkPolicy inheritance", func() {
+	ctx := context.Background()
+
+	It("should inherit CIDRs from NetworkPolicies selecting the backend pods", func() {
+		appLabels := map[string]string{"app": "inherit-app"}
+
+		service := &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "inherit-app", Namespace: "default"},
+			Spec: corev1.ServiceSpec{
+				Selector: appLabels,
+				Ports:    []corev1.ServicePort{{Port: 80, TargetPort: intstr.FromInt32(8080)}},
+			},
+		}
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "inherit-app", Namespace: "default", Labels: appLabels},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "app"}}},
+		}
+		appPort := intstr.FromInt32(8080)
+		metricsPort := intstr.FromInt32(9090)
+		policy := &networkingv1.NetworkPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "inherit-app", Namespace: "default"},
+			Spec: networkingv1.NetworkPolicySpec{
+				PodSelector: metav1.LabelSelector{MatchLabels: appLabels},
+				Ingress: []networkingv1.NetworkPolicyIngressRule{
+					{
+						Ports: []networkingv1.NetworkPolicyPort{{Port: &appPort}},
+						From:  []networkingv1.NetworkPolicyPeer{{IPBlock: &networkingv1.IPBlock{CIDR: "10.20.0.0/16"}}},
+					},
+					{
+						Ports: []networkingv1.NetworkPolicyPort{{Port: &metricsPort}},
+						From:  []networkingv1.NetworkPolicyPeer{{IPBlock: &networkingv1.IPBlock{CIDR: "10.30.0.0/16"}}},
+					},
+				},
+			},
+		}
+		unrelatedPolicy := &networkingv1.NetworkPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "other-app", Namespace: "default"},
+			Spec: networkingv1.NetworkPolicySpec{
+				PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{"app": "other-app"}},
+				Ingress: []networkingv1.NetworkPolicyIngressRule{{
+					From: []networkingv1.NetworkPolicyPeer{{IPBlock: &networkingv1.IPBlock{CIDR: "10.40.0.0/16"}}},
+				}},
+			},
+		}
+		ingress := newTestIngress("inherit-app", map[string]string{
+			AnnotationInheritBackendPolicies: "true",
+		})
+
+		Expect(k8sClient.Create(ctx, service)).To(Succeed())
+		Expect(k8sClient.Create(ctx, pod)).To(Succeed())
+		Expect(k8sClient.Create(ctx, policy)).To(Succeed())
+		Expect(k8sClient.Create(ctx, unrelatedPolicy)).To(Succeed())
+		Expect(k8sClient.Create(ctx, ingress)).To(Succeed())
+		defer func() {
+			Expect(k8sClient.Delete(ctx, ingress)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, unrelatedPolicy)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, policy)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, pod)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, service)).To(Succeed())
+		}()
+
+		controllerReconciler := &IngressReconciler{
+			Client: k8sClient,
+			Scheme: k8sClient.Scheme(),
+		}
+		_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+			NamespacedName: types.NamespacedName{Name: ingress.Name, Namespace: ingress.Namespace},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		updated := &networkingv1.Ingress{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: ingress.Name, Namespace: ingress.Namespace}, updated)).To(Succeed())
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxWhitelist, "10.20.0.0/16"))
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationInheritedPolicies, "inherit-app"))
+	})
+	It("should map backend Pods, Services and NetworkPolicies to the inheriting Ingresses", func() {
+		ensureNamespace(ctx, "inherit-team")
+		ensureNamespace(ctx, "inherit-unused")
+
+		appLabels := map[string]string{"app": "inherit-watch"}
+		service := &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "inherit-watch", Namespace: "inherit-team"},
+			Spec: corev1.ServiceSpec{
+				Selector: appLabels,
+				Ports:    []corev1.ServicePort{{Port: 80}},
+			},
+		}
+		inheriting := newTestIngress("inherit-watch", map[string]string{AnnotationInheritBackendPolicies: "true"})
+		inheriting.Namespace = "inherit-team"
+		canary := newTestIngress("inherit-watch-canary", map[string]string{AnnotationCanaryOf: "inherit-watch"})
+		canary.Namespace = "inherit-team"
+		other := newTestIngress("inherit-watch-other", map[string]string{AnnotationWhitelist: "10.0.0.0/24"})
+		other.Namespace = "inherit-team"
+		other.Spec.Rules[0].HTTP.Paths[0].Backend.Service.Name = "inherit-watch"
+		for _, obj := range []client.Object{service, inheriting, canary, other} {
+			Expect(k8sClient.Create(ctx, obj)).To(Succeed())
+		}
+		defer func() {
+			for _, obj := range []client.Object{service, inheriting, canary, other} {
+				Expect(k8sClient.Delete(ctx, obj)).To(Succeed())
+			}
+		}()
+
+		reconciler := &IngressReconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+		expected := []reconcile.Request{
+			{NamespacedName: client.ObjectKeyFromObject(inheriting)},
+			{NamespacedName: client.ObjectKeyFromObject(canary)},
+		}
+
+		pod := &metav1.PartialObjectMetadata{ObjectMeta: metav1.ObjectMeta{Name: "inherit-watch-1", Namespace: "inherit-team", Labels: appLabels}}
+		Expect(reconciler.ingressesInheritingFromPod(ctx, pod)).To(ConsistOf(expected))
+		pod.Labels = map[string]string{"app": "unrelated"}
+		Expect(reconciler.ingressesInheritingFromPod(ctx, pod)).To(BeEmpty())
+		Expect(reconciler.ingressesForService(ctx, service)).To(ConsistOf(expected))
+
+		// NetworkPolicies are only watched in namespaces with inheriting Ingresses
+		policyReconciler := &NetworkPolicyReconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+		Expect(policyReconciler.networkPolicyUsable(&networkingv1.NetworkPolicy{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "inherit-team"}})).To(BeTrue())
+		Expect(policyReconciler.networkPolicyUsable(&networkingv1.NetworkPolicy{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "inherit-unused"}})).To(BeFalse())
+		Expect(policyReconciler.networkPolicyUsable(&networkingv1.NetworkPolicy{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: DefaultNamespace}})).To(BeTrue())
+	})
+})
@@ -0,0 +1,128 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	v1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ingressnetworkpoliciesv1 "github.com/vitistack/ingressnetworkpolicy-operator/api/v1"
+)
+
+// errEmptyBreakGlass is returned when the break-glass policy of a lockdown resolves to no CIDRs,
+// which would leave the Ingress open to everyone.
+var errEmptyBreakGlass = errors.New("break-glass policy resolves to no CIDRs")
+
+// errLockdownNotSelected is returned when an Ingress requests a LockdownPolicy not selecting its namespace.
+var errLockdownNotSelected = errors.New("lockdown not selecting the namespace")
+
+// +kubebuilder:rbac:groups=ingressnetworkpolicies.vitistack.io,resources=lockdownpolicies,verbs=get;list;watch
+
+// lockdownForIngress returns the LockdownPolicy locking down the Ingress, or nil when it isn't locked down, and whether
+// the Ingress requested it itself. The lockdown annotation of the namespace takes precedence over the active policies
+// selecting the namespace, of which the first by name applies, which take precedence over the annotation of the Ingress.
+// An Ingress can only request policies whose namespaceSelector selects its namespace.
+func lockdownForIngress(ctx context.Context, r client.Reader, ingress *v1.Ingress) (*ingressnetworkpoliciesv1.LockdownPolicy, bool, error) {
+	namespace, err := getNamespaceMetadata(ctx, r, ingress.Namespace)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if name := strings.TrimSpace(namespace.GetAnnotations()[AnnotationLockdown]); name != "" {
+		policy, err := getLockdownPolicy(ctx, r, name)
+		return policy, false, err
+	}
+
+	policyList := ingressnetworkpoliciesv1.LockdownPolicyList{}
+	if err := r.List(ctx, &policyList); err != nil {
+		return nil, false, err
+	}
+	sort.Slice(policyList.Items, func(i, j int) bool { return policyList.Items[i].Name < policyList.Items[j].Name })
+
+	for _, policy := range policyList.Items {
+		if !policy.Spec.Active {
+			continue
+		}
+		selected, err := lockdownSelectsNamespace(&policy, namespace.GetLabels())
+		if err != nil {
+			return nil, false, err
+		}
+		if selected {
+			return &policy, false, nil
+		}
+	}
+
+	name := strings.TrimSpace(ingress.GetAnnotations()[AnnotationLockdown])
+	if name == "" {
+		return nil, false, nil
+	}
+	policy, err := getLockdownPolicy(ctx, r, name)
+	if err != nil {
+		return nil, false, err
+	}
+	selected, err := lockdownSelectsNamespace(policy, namespace.GetLabels())
+	if err != nil {
+		return nil, false, err
+	}
+	if !selected {
+		return nil, false, fmt.Errorf("%w: LockdownPolicy %s doesn't select namespace %s", errLockdownNotSelected, name, ingress.Namespace)
+	}
+	return policy, true, nil
+}
+
+// getLockdownPolicy fetches the LockdownPolicy by name.
+func getLockdownPolicy(ctx context.Context, r client.Reader, name string) (*ingressnetworkpoliciesv1.LockdownPolicy, error) {
+	policy := &ingressnetworkpoliciesv1.LockdownPolicy{}
+	if err := r.Get(ctx, client.ObjectKey{Name: name}, policy); err != nil {
+		return nil, fmt.Errorf("unable to fetch LockdownPolicy %s: %w", name, err)
+	}
+	return policy, nil
+}
+
+// lockdownSelectsNamespace reports whether the namespaceSelector of the LockdownPolicy selects a namespace with the labels,
+// policies without one select every namespace.
+func lockdownSelectsNamespace(policy *ingressnetworkpoliciesv1.LockdownPolicy, namespaceLabels map[string]string) (bool, error) {
+	if policy.Spec.NamespaceSelector == nil {
+		return true, nil
+	}
+	selector, err := metav1.LabelSelectorAsSelector(policy.Spec.NamespaceSelector)
+	if err != nil {
+		return false, fmt.Errorf("invalid namespaceSelector of LockdownPolicy %s: %w", policy.Name, err)
+	}
+	return selector.Matches(labels.Set(namespaceLabels)), nil
+}
+
+// namespaceLockedDown reports whether a lockdown applies to every Ingress of the namespace, through its annotation
+// or an active LockdownPolicy selecting it.
+func namespaceLockedDown(ctx context.Context, r client.Reader, namespace string) (bool, error) {
+	lockdown, _, err := lockdownForIngress(ctx, r, &v1.Ingress{ObjectMeta: metav1.ObjectMeta{Namespace: namespace}})
+	return lockdown != nil, err
+}
+
+// lockdownPolicySource returns the references and entries of the break-glass allowlist of the LockdownPolicy.
+func lockdownPolicySource(policy *ingressnetworkpoliciesv1.LockdownPolicy) clusterSources {
+	return clusterSources{
+		references: filterSliceFromString(policy.Spec.References),
+		entries:    filterSliceFromString(policy.Spec.Entries),
+	}
+}
+
+// createBreakGlassList resolves the break-glass allowlist of the LockdownPolicy for the Ingress.
+// Like the mandatory denylist, sources are resolved in the operator namespace and expired entries are dropped silently.
+func createBreakGlassList(ctx context.Context, r client.Reader, config AccessConfig, ingress *v1.Ingress, policy *ingressnetworkpoliciesv1.LockdownPolicy) (cidrList, error) {
+	source := lockdownPolicySource(policy)
+
+	list := createCidrList(ctx, r, config, ingress, DefaultNamespace, source.references, source.entries, nil, false)
+	list.expired = nil
+
+	if len(list.cidrs) == 0 {
+		return list, fmt.Errorf("%w: LockdownPolicy %s", errEmptyBreakGlass, policy.Name)
+	}
+	return list, nil
+}
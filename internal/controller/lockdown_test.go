@@ -0,0 +1,287 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	ingressnetworkpoliciesv1 "github.com/vitistack/ingressnetworkpolicy-operator/api/v1"
+)
+
+var _ = Describe("Lockdown", func() {
+	ctx := context.Background()
+
+	const namespace = "lockdown-team"
+
+	var opsVPN *networkingv1.NetworkPolicy
+	var lockdown *ingressnetworkpoliciesv1.LockdownPolicy
+
+	BeforeEach(func() {
+		ensureNamespace(ctx, DefaultNamespace)
+		team := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace, Labels: map[string]string{"tier": "critical"}}}
+		Expect(client.IgnoreAlreadyExists(k8sClient.Create(ctx, team))).To(Succeed())
+
+		opsVPN = &networkingv1.NetworkPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "ops-vpn", Namespace: DefaultNamespace},
+			Spec: networkingv1.NetworkPolicySpec{
+				Ingress: []networkingv1.NetworkPolicyIngressRule{{
+					From: []networkingv1.NetworkPolicyPeer{{IPBlock: &networkingv1.IPBlock{CIDR: "10.70.0.0/24"}}},
+				}},
+			},
+		}
+		Expect(k8sClient.Create(ctx, opsVPN)).To(Succeed())
+
+		lockdown = &ingressnetworkpoliciesv1.LockdownPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "ops-vpn"},
+			Spec: ingressnetworkpoliciesv1.LockdownPolicySpec{
+				References: []string{"ops-vpn"},
+				Entries:    []string{"192.0.2.10/32"},
+				Reason:     "Incident 4711",
+			},
+		}
+		Expect(k8sClient.Create(ctx, lockdown)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		Expect(k8sClient.Delete(ctx, lockdown)).To(Succeed())
+		Expect(k8sClient.Delete(ctx, opsVPN)).To(Succeed())
+	})
+
+	It("should replace the allowlist while the Ingress is locked down and restore it when lifted", func() {
+		ingress := newTestIngress("lockdown-app", map[string]string{
+			AnnotationWhitelist: "10.70.0.0/16",
+			AnnotationDenylist:  "10.70.0.128/25",
+			AnnotationLockdown:  "ops-vpn",
+		})
+		ingress.Namespace = namespace
+		Expect(k8sClient.Create(ctx, ingress)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, ingress)).To(Succeed()) }()
+
+		recorder := record.NewFakeRecorder(10)
+		ingressKey := client.ObjectKeyFromObject(ingress)
+		reconciler := &IngressReconciler{Client: k8sClient, Scheme: k8sClient.Scheme(), Access: AccessConfig{Recorder: recorder}}
+
+		updated := reconcileAndGet(reconciler, ingressKey)
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxWhitelist, "10.70.0.0/24,192.0.2.10/32"))
+		// The denylist keeps applying during a lockdown
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxDenylist, "10.70.0.128/25"))
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationAppliedLockdown, "ops-vpn"))
+		Eventually(recorder.Events).Should(Receive(ContainSubstring("Locked down to the break-glass policy ops-vpn: Incident 4711")))
+
+		// Changes of the break-glass policy reach every managed Ingress
+		Expect(reconciler.ingressesWithAccess(ctx, lockdown)).To(ContainElement(reconcile.Request{NamespacedName: ingressKey}))
+
+		delete(updated.Annotations, AnnotationLockdown)
+		Expect(k8sClient.Update(ctx, updated)).To(Succeed())
+		updated = reconcileAndGet(reconciler, ingressKey)
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxWhitelist, "10.70.0.0/16"))
+		Expect(updated.Annotations).NotTo(HaveKey(AnnotationAppliedLockdown))
+		Eventually(recorder.Events).Should(Receive(ContainSubstring(EventReasonLockdownLifted)))
+	})
+
+	It("should lock down the Ingresses of a namespace and of namespaces selected by an active policy", func() {
+		ingress := newTestIngress("lockdown-namespace-app", map[string]string{AnnotationWhitelist: "10.70.0.0/16"})
+		ingress.Namespace = namespace
+		Expect(k8sClient.Create(ctx, ingress)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, ingress)).To(Succeed()) }()
+
+		ingressKey := client.ObjectKeyFromObject(ingress)
+		reconciler := &IngressReconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+
+		team := &corev1.Namespace{}
+		Expect(k8sClient.Get(ctx, client.ObjectKey{Name: namespace}, team)).To(Succeed())
+		team.Annotations = map[string]string{AnnotationLockdown: "ops-vpn"}
+		Expect(k8sClient.Update(ctx, team)).To(Succeed())
+
+		updated := reconcileAndGet(reconciler, ingressKey)
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxWhitelist, "10.70.0.0/24,192.0.2.10/32"))
+
+		delete(team.Annotations, AnnotationLockdown)
+		Expect(k8sClient.Update(ctx, team)).To(Succeed())
+		updated = reconcileAndGet(reconciler, ingressKey)
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxWhitelist, "10.70.0.0/16"))
+
+		// The cluster-wide switch only applies to the namespaces selected by the policy
+		lockdown.Spec.Active = true
+		lockdown.Spec.NamespaceSelector = &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "critical"}}
+		Expect(k8sClient.Update(ctx, lockdown)).To(Succeed())
+
+		updated = reconcileAndGet(reconciler, ingressKey)
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxWhitelist, "10.70.0.0/24,192.0.2.10/32"))
+
+		other := newTestIngress("lockdown-other-app", map[string]string{AnnotationWhitelist: "10.70.0.0/16"})
+		ensureNamespace(ctx, other.Namespace)
+		Expect(k8sClient.Create(ctx, other)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, other)).To(Succeed()) }()
+		updated = reconcileAndGet(reconciler, client.ObjectKeyFromObject(other))
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxWhitelist, "10.70.0.0/16"))
+		Expect(updated.Annotations).NotTo(HaveKey(AnnotationAppliedLockdown))
+	})
+
+	It("should let namespace lockdowns win over the Ingress and check the guardrails of lockdowns it requests", func() {
+		tenantOpen := &ingressnetworkpoliciesv1.LockdownPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "tenant-open"},
+			Spec: ingressnetworkpoliciesv1.LockdownPolicySpec{
+				Entries:           []string{"0.0.0.0/0"},
+				NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "public"}},
+			},
+		}
+		Expect(k8sClient.Create(ctx, tenantOpen)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, tenantOpen)).To(Succeed()) }()
+		guardrail := &ingressnetworkpoliciesv1.AccessGuardrail{
+			ObjectMeta: metav1.ObjectMeta{Name: "lockdown-critical"},
+			Spec: ingressnetworkpoliciesv1.AccessGuardrailSpec{
+				NamespaceSelector:   &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "critical"}},
+				MinPrefixLengthIPv4: ptr.To[int32](8),
+			},
+		}
+		Expect(k8sClient.Create(ctx, guardrail)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, guardrail)).To(Succeed()) }()
+
+		ingress := newTestIngress("lockdown-tenant-app", map[string]string{
+			AnnotationWhitelist: "10.70.0.0/16",
+			AnnotationLockdown:  "tenant-open",
+		})
+		ingress.Namespace = namespace
+		Expect(k8sClient.Create(ctx, ingress)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, ingress)).To(Succeed()) }()
+
+		recorder := record.NewFakeRecorder(10)
+		ingressKey := client.ObjectKeyFromObject(ingress)
+		reconciler := &IngressReconciler{Client: k8sClient, Scheme: k8sClient.Scheme(), Access: AccessConfig{Recorder: recorder}}
+
+		// An Ingress can only request policies selecting its namespace
+		_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: ingressKey})
+		Expect(errors.Is(err, errLockdownNotSelected)).To(BeTrue())
+		updated := &networkingv1.Ingress{}
+		Expect(k8sClient.Get(ctx, ingressKey, updated)).To(Succeed())
+		Expect(updated.Annotations).NotTo(HaveKey(AnnotationNginxWhitelist))
+		Eventually(recorder.Events).Should(Receive(ContainSubstring(EventReasonLockdownFailed)))
+
+		// Requested break-glass policies are refused like the allowlist when violating a guardrail
+		tenantOpen.Spec.NamespaceSelector = nil
+		Expect(k8sClient.Update(ctx, tenantOpen)).To(Succeed())
+		updated = reconcileAndGet(reconciler, ingressKey)
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxWhitelist, "0.0.0.0/32,::/128"))
+		Expect(updated.Annotations).NotTo(HaveKey(AnnotationAppliedLockdown))
+		Eventually(recorder.Events).Should(Receive(ContainSubstring(EventReasonGuardrailViolation)))
+
+		// The lockdown of the namespace wins over the one requested by the Ingress
+		team := &corev1.Namespace{}
+		Expect(k8sClient.Get(ctx, client.ObjectKey{Name: namespace}, team)).To(Succeed())
+		team.Annotations = map[string]string{AnnotationLockdown: "ops-vpn"}
+		Expect(k8sClient.Update(ctx, team)).To(Succeed())
+		defer func() {
+			delete(team.Annotations, AnnotationLockdown)
+			Expect(k8sClient.Update(ctx, team)).To(Succeed())
+		}()
+
+		updated = reconcileAndGet(reconciler, ingressKey)
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxWhitelist, "10.70.0.0/24,192.0.2.10/32"))
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationAppliedLockdown, "ops-vpn"))
+	})
+
+	It("should lock down Ingresses not managed by the operator and leave them untouched when lifted", func() {
+		unmanaged := newTestIngress("lockdown-unmanaged-app", nil)
+		unmanaged.Namespace = namespace
+		Expect(k8sClient.Create(ctx, unmanaged)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, unmanaged)).To(Succeed()) }()
+		manual := newTestIngress("lockdown-manual-app", map[string]string{AnnotationNginxWhitelist: "198.51.100.0/24"})
+		manual.Namespace = namespace
+		Expect(k8sClient.Create(ctx, manual)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, manual)).To(Succeed()) }()
+
+		unmanagedKey := client.ObjectKeyFromObject(unmanaged)
+		manualKey := client.ObjectKeyFromObject(manual)
+		reconciler := &IngressReconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+
+		// Without a lockdown they are not reconciled, and left untouched when they are
+		team := &corev1.Namespace{}
+		Expect(k8sClient.Get(ctx, client.ObjectKey{Name: namespace}, team)).To(Succeed())
+		Expect(reconciler.ingressesInNamespace(ctx, team)).NotTo(ContainElement(reconcile.Request{NamespacedName: unmanagedKey}))
+		updated := reconcileAndGet(reconciler, unmanagedKey)
+		Expect(updated.Annotations).To(BeEmpty())
+
+		team.Annotations = map[string]string{AnnotationLockdown: "ops-vpn"}
+		Expect(k8sClient.Update(ctx, team)).To(Succeed())
+		Expect(reconciler.ingressesInNamespace(ctx, team)).To(ContainElements(
+			reconcile.Request{NamespacedName: unmanagedKey}, reconcile.Request{NamespacedName: manualKey}))
+
+		updated = reconcileAndGet(reconciler, unmanagedKey)
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxWhitelist, "10.70.0.0/24,192.0.2.10/32"))
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationAppliedLockdown, "ops-vpn"))
+
+		// An allowlist written by hand is replaced by the break-glass list, and saved until the lockdown is lifted
+		updated = reconcileAndGet(reconciler, manualKey)
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxWhitelist, "10.70.0.0/24,192.0.2.10/32"))
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationSavedAllowlist, "198.51.100.0/24"))
+
+		delete(team.Annotations, AnnotationLockdown)
+		Expect(k8sClient.Update(ctx, team)).To(Succeed())
+		Expect(reconciler.ingressesInNamespace(ctx, team)).To(ContainElements(
+			reconcile.Request{NamespacedName: unmanagedKey}, reconcile.Request{NamespacedName: manualKey}))
+		updated = reconcileAndGet(reconciler, unmanagedKey)
+		Expect(updated.Annotations).NotTo(HaveKey(AnnotationNginxWhitelist))
+		Expect(updated.Annotations).NotTo(HaveKey(AnnotationAppliedLockdown))
+
+		updated = reconcileAndGet(reconciler, manualKey)
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxWhitelist, "198.51.100.0/24"))
+		Expect(updated.Annotations).NotTo(HaveKey(AnnotationSavedAllowlist))
+		Expect(updated.Annotations).NotTo(HaveKey(AnnotationManagedAllowlistKeys))
+		Expect(updated.Annotations).NotTo(HaveKey(AnnotationAppliedLockdown))
+
+		// Active policies reach every Ingress
+		lockdown.Spec.Active = true
+		Expect(k8sClient.Update(ctx, lockdown)).To(Succeed())
+		Expect(reconciler.ingressesForLockdown(ctx, lockdown)).To(ContainElements(
+			reconcile.Request{NamespacedName: unmanagedKey}, reconcile.Request{NamespacedName: manualKey}))
+	})
+
+	It("should keep the current access when the break-glass policy resolves to no CIDRs", func() {
+		lockdown.Spec.References = []string{"missing-policy"}
+		lockdown.Spec.Entries = nil
+		Expect(k8sClient.Update(ctx, lockdown)).To(Succeed())
+
+		ingress := newTestIngress("lockdown-empty-app", map[string]string{
+			AnnotationWhitelist: "10.70.0.0/16",
+			AnnotationLockdown:  "ops-vpn",
+		})
+		ingress.Namespace = namespace
+		Expect(k8sClient.Create(ctx, ingress)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, ingress)).To(Succeed()) }()
+
+		recorder := record.NewFakeRecorder(10)
+		reconciler := &IngressReconciler{Client: k8sClient, Scheme: k8sClient.Scheme(), Access: AccessConfig{Recorder: recorder}}
+		_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(ingress)})
+		Expect(errors.Is(err, errEmptyBreakGlass)).To(BeTrue())
+		updated := &networkingv1.Ingress{}
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(ingress), updated)).To(Succeed())
+		Expect(updated.Annotations).NotTo(HaveKey(AnnotationNginxWhitelist))
+		Eventually(recorder.Events).Should(Receive(ContainSubstring(EventReasonLockdownFailed)))
+	})
+})
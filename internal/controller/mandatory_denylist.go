@@ -0,0 +1,109 @@
+package controller
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/networking/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	ingressnetworkpoliciesv1 "github.com/vitistack/ingressnetworkpolicy-operator/api/v1"
+)
+
+// +kubebuilder:rbac:groups=ingressnetworkpolicies.vitistack.io,resources=mandatorydenylists,verbs=get;list;watch
+
+// clusterSources are the references and entries of the MandatoryDenylists or a LockdownPolicy.
+// They are resolved in the operator namespace, so the Ingress namespace can neither provide nor refuse them.
+type clusterSources struct {
+	references []string
+	entries    []string
+}
+
+// annotations returns the sources as the list annotations of an Ingress, so they are matched like the lists of an Ingress.
+func (s clusterSources) annotations() map[string]string {
+	return map[string]string{
+		AnnotationWhiteListNetworkPolicy: strings.Join(s.references, ","),
+		AnnotationWhitelist:              strings.Join(s.entries, ","),
+	}
+}
+
+// getMandatoryDenylist returns the references and entries of all MandatoryDenylists and EmergencyBlocks,
+// and the CIDRs of the active EmergencyBlocks. It returns nil when there are neither MandatoryDenylists nor EmergencyBlocks.
+func getMandatoryDenylist(ctx context.Context, r client.Reader) (*clusterSources, []string, error) {
+	denylistList := ingressnetworkpoliciesv1.MandatoryDenylistList{}
+	if err := r.List(ctx, &denylistList); err != nil {
+		return nil, nil, err
+	}
+	blockList := ingressnetworkpoliciesv1.EmergencyBlockList{}
+	if err := r.List(ctx, &blockList); err != nil {
+		return nil, nil, err
+	}
+	if len(denylistList.Items) == 0 && len(blockList.Items) == 0 {
+		return nil, nil, nil
+	}
+
+	sources := &clusterSources{}
+	var blocks []string
+	for _, denylist := range denylistList.Items {
+		sources.references = append(sources.references, filterSliceFromString(denylist.Spec.References)...)
+		sources.entries = append(sources.entries, filterSliceFromString(denylist.Spec.Entries)...)
+	}
+	for _, block := range blockList.Items {
+		sources.entries = append(sources.entries, formatEntryExpiry(block.Spec.CIDR, &block.Spec.Expires))
+		if time.Now().Before(block.Spec.Expires.Time) {
+			blocks = append(blocks, block.Spec.CIDR)
+		}
+	}
+
+	return sources, sortSlice(blocks), nil
+}
+
+// createMandatoryDenylist resolves the MandatoryDenylists and EmergencyBlocks for the Ingress, and returns
+// the CIDRs of the active EmergencyBlocks. Expired entries are dropped without being recorded on the Ingress.
+func createMandatoryDenylist(ctx context.Context, r client.Reader, config AccessConfig, ingress *v1.Ingress) (cidrList, []string, error) {
+	sources, blocks, err := getMandatoryDenylist(ctx, r)
+	if err != nil || sources == nil {
+		return cidrList{}, nil, err
+	}
+
+	list := createCidrList(ctx, r, config, ingress, DefaultNamespace, sources.references, sources.entries, nil, true)
+	list.expired = nil
+
+	return list, blocks, nil
+}
+
+// clusterPoliciesUse reports whether the MandatoryDenylists or LockdownPolicies depend on a changed object,
+// according to match. Both are matched as the annotations of an Ingress in the operator namespace.
+func clusterPoliciesUse(ctx context.Context, r client.Reader, match func(annotations map[string]string) bool) bool {
+	log := logf.FromContext(ctx)
+
+	mandatory, _, err := getMandatoryDenylist(ctx, r)
+	if err != nil {
+		log.Error(err, "unable to list MandatoryDenylists")
+		return false
+	}
+	if mandatory != nil && match(mandatory.annotations()) {
+		return true
+	}
+
+	policyList := ingressnetworkpoliciesv1.LockdownPolicyList{}
+	if err := r.List(ctx, &policyList); err != nil {
+		log.Error(err, "unable to list LockdownPolicies")
+		return false
+	}
+	for _, policy := range policyList.Items {
+		if match(lockdownPolicySource(&policy).annotations()) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// rendersDenyWithAllow reports whether the renderer renders the denylist alongside an allowlist.
+// The denylist is subtracted from the allowlist for renderers that don't.
+func rendersDenyWithAllow(renderer accessRenderer) bool {
+	return renderer.name() != RendererApisix
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	ingressnetworkpoliciesv1 "github.com/vitistack/ingressnetworkpolicy-operator/api/v1"
+)
+
+var _ = Describe("Mandatory denylist", func() {
+	ctx := context.Background()
+
+	var abuseRanges *networkingv1.NetworkPolicy
+	var mandatory *ingressnetworkpoliciesv1.MandatoryDenylist
+
+	BeforeEach(func() {
+		ensureNamespace(ctx, "default")
+		ensureNamespace(ctx, DefaultNamespace)
+
+		abuseRanges = &networkingv1.NetworkPolicy{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "abuse-ranges",
+				Namespace: DefaultNamespace,
+				// Mandatory references are resolved regardless of the namespaces allowed to reference them
+				Annotations: map[string]string{AnnotationAllowedNamespaces: "security"},
+			},
+			Spec: networkingv1.NetworkPolicySpec{
+				Ingress: []networkingv1.NetworkPolicyIngressRule{{
+					From: []networkingv1.NetworkPolicyPeer{{IPBlock: &networkingv1.IPBlock{CIDR: "10.90.66.0/24"}}},
+				}},
+			},
+		}
+		Expect(k8sClient.Create(ctx, abuseRanges)).To(Succeed())
+
+		mandatory = &ingressnetworkpoliciesv1.MandatoryDenylist{
+			ObjectMeta: metav1.ObjectMeta{Name: "security"},
+			Spec: ingressnetworkpoliciesv1.MandatoryDenylistSpec{
+				References: []string{"abuse-ranges"},
+				Entries:    []string{"198.51.100.0/24"},
+			},
+		}
+		Expect(k8sClient.Create(ctx, mandatory)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		Expect(k8sClient.Delete(ctx, mandatory)).To(Succeed())
+		Expect(k8sClient.Delete(ctx, abuseRanges)).To(Succeed())
+	})
+
+	It("should merge the mandatory denylist into the denylist of every managed Ingress", func() {
+		ingress := newTestIngress("mandatory-app", map[string]string{
+			AnnotationWhitelist: "10.90.0.0/16",
+			AnnotationDenylist:  "10.90.1.0/24",
+		})
+		Expect(k8sClient.Create(ctx, ingress)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, ingress)).To(Succeed()) }()
+
+		ingressKey := types.NamespacedName{Name: ingress.Name, Namespace: ingress.Namespace}
+		ingressReconciler := &IngressReconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+		_, err := ingressReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: ingressKey})
+		Expect(err).NotTo(HaveOccurred())
+
+		updated := &networkingv1.Ingress{}
+		Expect(k8sClient.Get(ctx, ingressKey, updated)).To(Succeed())
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxWhitelist, "10.90.0.0/16"))
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxDenylist, "10.90.1.0/24,10.90.66.0/24,198.51.100.0/24"))
+		// Findings only cover the lists of the Ingress
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationAccessFindings, "DenylistHoles=10.90.1.0/24"))
+
+		// Every managed Ingress is recomputed when the mandatory denylist or its sources change
+		request := reconcile.Request{NamespacedName: ingressKey}
+		Expect(ingressReconciler.ingressesWithAccess(ctx, mandatory)).To(ContainElement(request))
+
+		abuseRanges.Spec.Ingress[0].From[0].IPBlock.CIDR = "10.90.67.0/24"
+		Expect(k8sClient.Update(ctx, abuseRanges)).To(Succeed())
+		policyReconciler := &NetworkPolicyReconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+		_, err = policyReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(abuseRanges)})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(k8sClient.Get(ctx, ingressKey, updated)).To(Succeed())
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxDenylist, "10.90.1.0/24,10.90.67.0/24,198.51.100.0/24"))
+
+		// Unmanaged Ingresses are left alone
+		unmanaged := newTestIngress("unmanaged-mandatory-app", nil)
+		Expect(k8sClient.Create(ctx, unmanaged)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, unmanaged)).To(Succeed()) }()
+		Expect(ingressReconciler.ingressesWithAccess(ctx, mandatory)).NotTo(ContainElement(
+			reconcile.Request{NamespacedName: client.ObjectKeyFromObject(unmanaged)},
+		))
+	})
+
+	It("should map sources referenced by the mandatory denylist to every managed Ingress", func() {
+		mandatory.Spec.References = append(mandatory.Spec.References, "cidrset:sanctioned-networks")
+		Expect(k8sClient.Update(ctx, mandatory)).To(Succeed())
+
+		ingress := newTestIngress("mandatory-source-app", map[string]string{AnnotationWhitelist: "10.90.0.0/16"})
+		Expect(k8sClient.Create(ctx, ingress)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, ingress)).To(Succeed()) }()
+
+		ingressReconciler := &IngressReconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+		set := &ingressnetworkpoliciesv1.CIDRSet{ObjectMeta: metav1.ObjectMeta{Name: "sanctioned-networks", Namespace: DefaultNamespace}}
+		Expect(ingressReconciler.ingressesForSource(SourceCIDRSet)(ctx, set)).To(ContainElement(
+			reconcile.Request{NamespacedName: client.ObjectKeyFromObject(ingress)},
+		))
+
+		set.Namespace = "default"
+		Expect(ingressReconciler.ingressesForSource(SourceCIDRSet)(ctx, set)).To(BeEmpty())
+	})
+})
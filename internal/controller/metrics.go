@@ -0,0 +1,18 @@
+package controller
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	// accessFindingsTotal counts the findings of the access analysis reported on Ingresses.
+	accessFindingsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "ingressnetworkpolicy_access_findings_total",
+		Help: "Number of changed allow- and denylist overlap findings reported on Ingresses, by finding.",
+	}, []string{"finding"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(accessFindingsTotal)
+}
@@ -0,0 +1,114 @@
+package controller
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	ingressnetworkpoliciesv1 "github.com/vitistack/ingressnetworkpolicy-operator/api/v1"
+)
+
+// namespaceDefaults are the default references of a namespace applying to an Ingress.
+type namespaceDefaults struct {
+	whitelist []string
+	denylist  []string
+	// optedOut is set when the Ingress opted out of the defaults.
+	optedOut bool
+	// optOutRefused is set when the Ingress opted out, but a guardrail of the namespace forbids it.
+	optOutRefused bool
+}
+
+// references returns the default references of both lists.
+func (d namespaceDefaults) references() []string {
+	return slices.Concat(d.whitelist, d.denylist)
+}
+
+// getNamespaceMetadata returns the metadata of the namespace, which is empty when it does not exist.
+func getNamespaceMetadata(ctx context.Context, r client.Reader, name string) (*metav1.PartialObjectMetadata, error) {
+	namespace := &metav1.PartialObjectMetadata{}
+	namespace.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("Namespace"))
+	if err := r.Get(ctx, client.ObjectKey{Name: name}, namespace); err != nil {
+		return &metav1.PartialObjectMetadata{}, client.IgnoreNotFound(err)
+	}
+	return namespace, nil
+}
+
+// getNamespaceAnnotations returns the annotations of the namespace, or nil when it does not exist.
+func getNamespaceAnnotations(ctx context.Context, r client.Reader, name string) (map[string]string, error) {
+	namespace, err := getNamespaceMetadata(ctx, r, name)
+	if err != nil {
+		return nil, err
+	}
+	return namespace.GetAnnotations(), nil
+}
+
+// namespaceHasDefaults reports whether the namespace annotations define default references.
+func namespaceHasDefaults(annotations map[string]string) bool {
+	return strings.TrimSpace(annotations[AnnotationDefaultWhiteListPolicy]) != "" ||
+		strings.TrimSpace(annotations[AnnotationDefaultDenyListPolicy]) != ""
+}
+
+// namespaceDefaultsForIngress returns the default references of the Ingress namespace applying to the Ingress.
+// The Ingress extends the defaults with its own references unless it sets the namespace-defaults annotation:
+// with replace, its own allow- or denylist replaces the defaults of that list, and with opt-out no defaults apply,
+// unless a guardrail of the namespace forbids it.
+func namespaceDefaultsForIngress(ctx context.Context, r client.Reader, ingress *v1.Ingress) (namespaceDefaults, error) {
+	log := logf.FromContext(ctx)
+
+	annotations, err := getNamespaceAnnotations(ctx, r, ingress.Namespace)
+	if err != nil {
+		return namespaceDefaults{}, err
+	}
+
+	defaults := namespaceDefaults{
+		whitelist: filterSliceFromString(strings.Split(annotations[AnnotationDefaultWhiteListPolicy], ",")),
+		denylist:  filterSliceFromString(strings.Split(annotations[AnnotationDefaultDenyListPolicy], ",")),
+	}
+	if len(defaults.whitelist) == 0 && len(defaults.denylist) == 0 {
+		return defaults, nil
+	}
+
+	switch mode := strings.TrimSpace(ingress.GetAnnotations()[AnnotationNamespaceDefaults]); mode {
+	case "", NamespaceDefaultsExtend:
+	case NamespaceDefaultsReplace:
+		if ingressHasAnyAnnotation(ingress, AnnotationWhiteListNetworkPolicy, AnnotationWhiteListPolicySelector, AnnotationWhitelist) {
+			defaults.whitelist = nil
+		}
+		if ingressHasAnyAnnotation(ingress, AnnotationDenyListNetworkPolicy, AnnotationDenyListPolicySelector, AnnotationDenylist) {
+			defaults.denylist = nil
+		}
+	case NamespaceDefaultsOptOut:
+		guardrails, err := guardrailsForNamespace(ctx, r, ingress.Namespace)
+		if err != nil {
+			return namespaceDefaults{}, err
+		}
+		if slices.ContainsFunc(guardrails, func(guardrail ingressnetworkpoliciesv1.AccessGuardrail) bool {
+			return guardrail.Spec.ForbidDefaultsOptOut
+		}) {
+			defaults.optOutRefused = true
+			return defaults, nil
+		}
+		return namespaceDefaults{optedOut: true}, nil
+	default:
+		// Unknown modes keep the defaults, which is the restrictive choice
+		log.Info("unknown namespace defaults mode for Ingress, extending the defaults", "Ingress.Name", ingress.Name, "Mode", mode)
+	}
+
+	return defaults, nil
+}
+
+// ingressHasAnyAnnotation reports whether one of the annotations is set on the Ingress.
+func ingressHasAnyAnnotation(ingress client.Object, annotations ...string) bool {
+	for _, annotation := range annotations {
+		if strings.TrimSpace(ingress.GetAnnotations()[annotation]) != "" {
+			return true
+		}
+	}
+	return false
+}
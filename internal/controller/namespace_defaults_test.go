@@ -0,0 +1,155 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	ingressnetworkpoliciesv1 "github.com/vitistack/ingressnetworkpolicy-operator/api/v1"
+)
+
+var _ = Describe("Namespace defaults", func() {
+	ctx := context.Background()
+
+	const namespace = "defaults-team"
+
+	var policies []*networkingv1.NetworkPolicy
+
+	reconcileIngress := func(reconciler *IngressReconciler, annotations map[string]string) *networkingv1.Ingress {
+		ingress := newTestIngress("defaults-app", annotations)
+		ingress.Namespace = namespace
+		Expect(k8sClient.Create(ctx, ingress)).To(Succeed())
+		DeferCleanup(func() { Expect(k8sClient.Delete(ctx, ingress)).To(Succeed()) })
+
+		return reconcileAndGet(reconciler, client.ObjectKeyFromObject(ingress))
+	}
+
+	BeforeEach(func() {
+		ensureNamespace(ctx, DefaultNamespace)
+		team := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name: namespace,
+			Annotations: map[string]string{
+				AnnotationDefaultWhiteListPolicy: "corporate-network",
+				AnnotationDefaultDenyListPolicy:  "blocked-ranges",
+			},
+		}}
+		Expect(client.IgnoreAlreadyExists(k8sClient.Create(ctx, team))).To(Succeed())
+
+		policies = []*networkingv1.NetworkPolicy{
+			newTestPolicy("corporate-network", nil, "10.80.0.0/16"),
+			newTestPolicy("blocked-ranges", nil, "10.80.66.0/24"),
+			newTestPolicy("partner-network", nil, "192.0.2.0/24"),
+		}
+		for _, policy := range policies {
+			Expect(k8sClient.Create(ctx, policy)).To(Succeed())
+		}
+	})
+
+	AfterEach(func() {
+		for _, policy := range policies {
+			Expect(k8sClient.Delete(ctx, policy)).To(Succeed())
+		}
+	})
+
+	It("should restrict Ingresses without annotations and extend the defaults with their own references", func() {
+		reconciler := &IngressReconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+
+		updated := reconcileIngress(reconciler, nil)
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxWhitelist, "10.80.0.0/16"))
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxDenylist, "10.80.66.0/24"))
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationAppliedDefaults, "corporate-network,blocked-ranges"))
+
+		// Changing a default policy recomputes the Ingresses it applies to
+		policyReconciler := &NetworkPolicyReconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+		policies[0].Spec.Ingress[0].From[0].IPBlock.CIDR = "10.81.0.0/16"
+		Expect(k8sClient.Update(ctx, policies[0])).To(Succeed())
+		_, err := policyReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(policies[0])})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(updated), updated)).To(Succeed())
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxWhitelist, "10.81.0.0/16"))
+	})
+
+	It("should extend or replace the defaults with the references of the Ingress", func() {
+		reconciler := &IngressReconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+
+		updated := reconcileIngress(reconciler, map[string]string{AnnotationWhiteListNetworkPolicy: "partner-network"})
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxWhitelist, "10.80.0.0/16,192.0.2.0/24"))
+
+		updated.Annotations[AnnotationNamespaceDefaults] = NamespaceDefaultsReplace
+		Expect(k8sClient.Update(ctx, updated)).To(Succeed())
+		_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(updated)})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(updated), updated)).To(Succeed())
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxWhitelist, "192.0.2.0/24"))
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxDenylist, "10.80.66.0/24"))
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationAppliedDefaults, "blocked-ranges"))
+	})
+
+	It("should leave Ingresses opting out untouched", func() {
+		reconciler := &IngressReconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+
+		updated := reconcileIngress(reconciler, map[string]string{
+			AnnotationNamespaceDefaults: NamespaceDefaultsOptOut,
+			AnnotationNginxWhitelist:    "203.0.113.0/24",
+		})
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxWhitelist, "203.0.113.0/24"))
+		Expect(updated.Annotations).NotTo(HaveKey(AnnotationAppliedDefaults))
+	})
+
+	It("should refuse opting out when a guardrail forbids it", func() {
+		guardrail := &ingressnetworkpoliciesv1.AccessGuardrail{
+			ObjectMeta: metav1.ObjectMeta{Name: "no-opt-out"},
+			Spec:       ingressnetworkpoliciesv1.AccessGuardrailSpec{ForbidDefaultsOptOut: true},
+		}
+		Expect(k8sClient.Create(ctx, guardrail)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, guardrail)).To(Succeed()) }()
+
+		recorder := record.NewFakeRecorder(10)
+		reconciler := &IngressReconciler{Client: k8sClient, Scheme: k8sClient.Scheme(), Access: AccessConfig{Recorder: recorder}}
+
+		updated := reconcileIngress(reconciler, map[string]string{AnnotationNamespaceDefaults: NamespaceDefaultsOptOut})
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxWhitelist, "10.80.0.0/16"))
+		Expect(recorder.Events).To(Receive(ContainSubstring(EventReasonOptOutRefused)))
+	})
+
+	It("should map namespace changes to every Ingress when the namespace has defaults", func() {
+		reconciler := &IngressReconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+		ingress := newTestIngress("unmanaged-app", nil)
+		ingress.Namespace = namespace
+		Expect(k8sClient.Create(ctx, ingress)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, ingress)).To(Succeed()) }()
+
+		team := &corev1.Namespace{}
+		Expect(k8sClient.Get(ctx, client.ObjectKey{Name: namespace}, team)).To(Succeed())
+		Expect(reconciler.ingressesInNamespace(ctx, team)).To(ContainElement(
+			reconcile.Request{NamespacedName: client.ObjectKeyFromObject(ingress)},
+		))
+
+		team.Annotations = nil
+		Expect(reconciler.ingressesInNamespace(ctx, team)).To(BeEmpty())
+	})
+})
@@ -0,0 +1,82 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	ingressnetworkpoliciesv1 "github.com/vitistack/ingressnetworkpolicy-operator/api/v1"
+)
+
+// NetBoxPrefixSourceReconciler reconciles a NetBoxPrefixSource object
+type NetBoxPrefixSourceReconciler struct {
+	client.Client
+	Scheme     *runtime.Scheme
+	HTTPClient *http.Client
+}
+
+// +kubebuilder:rbac:groups=ingressnetworkpolicies.vitistack.io,resources=netboxprefixsources,verbs=get;list;watch
+// +kubebuilder:rbac:groups=ingressnetworkpolicies.vitistack.io,resources=netboxprefixsources/status,verbs=get;update;patch
+
+// Reconcile queries NetBox when the refresh interval has passed or the spec changed,
+// and keeps the last good prefixes in the status when the query fails.
+// Ingresses referencing the source are updated through the status change.
+func (r *NetBoxPrefixSourceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := logf.FromContext(ctx)
+
+	var source ingressnetworkpoliciesv1.NetBoxPrefixSource
+	if err := r.Get(ctx, req.NamespacedName, &source); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	original := source.Status.DeepCopy()
+
+	httpClient := r.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	requeueAfter := refreshCIDRSource(ctx, &source.Status, source.Generation, source.Spec.RefreshInterval.Duration, source.Spec.MaxAge, func() ([]string, error) {
+		log.Info("Querying NetBox prefixes", "NetBoxPrefixSource.Name", source.Name, "URL", source.Spec.URL)
+		return fetchNetBoxPrefixes(ctx, r, httpClient, &source)
+	})
+
+	if !equality.Semantic.DeepEqual(original, &source.Status) {
+		if err := r.Status().Update(ctx, &source); err != nil {
+			log.Error(err, "unable to update NetBoxPrefixSource status", "NetBoxPrefixSource.Name", source.Name)
+			return ctrl.Result{}, err
+		}
+	}
+
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *NetBoxPrefixSourceReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&ingressnetworkpoliciesv1.NetBoxPrefixSource{}).
+		Named("netboxprefixsource").
+		Complete(r)
+}
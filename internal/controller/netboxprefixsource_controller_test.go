@@ -0,0 +1,178 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	ingressnetworkpoliciesv1 "github.com/vitistack/ingressnetworkpolicy-operator/api/v1"
+)
+
+// fakeNetBoxPrefix is a prefix served by the fake NetBox API.
+type fakeNetBoxPrefix struct {
+	prefix string
+	tags   []string
+	site   string
+}
+
+// newFakeNetBox serves the prefixes matching the tag and site filters, one prefix per page.
+func newFakeNetBox(token string, prefixes []fakeNetBoxPrefix) *httptest.Server {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Token "+token {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if r.URL.Path != "/api/ipam/prefixes/" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		query := r.URL.Query()
+		var matching []string
+		for _, prefix := range prefixes {
+			if query.Has("site") && !slices.Contains(query["site"], prefix.site) {
+				continue
+			}
+			if !slices.ContainsFunc(query["tag"], func(tag string) bool { return !slices.Contains(prefix.tags, tag) }) {
+				matching = append(matching, prefix.prefix)
+			}
+		}
+
+		offset := 0
+		if query.Get("offset") != "" {
+			offset = 1
+		}
+
+		page := map[string]any{"count": len(matching), "next": nil, "results": []map[string]string{}}
+		if offset < len(matching) {
+			page["results"] = []map[string]string{{"prefix": matching[offset]}}
+		}
+		if offset == 0 && len(matching) > 1 {
+			next := r.URL
+			next.Scheme, next.Host = "http", r.Host
+			values := next.Query()
+			values.Set("offset", "1")
+			next.RawQuery = values.Encode()
+			page["next"] = next.String()
+		}
+		_ = json.NewEncoder(w).Encode(page)
+	}))
+	return server
+}
+
+var _ = Describe("NetBoxPrefixSource Controller", func() {
+	ctx := context.Background()
+
+	It("should query prefixes by filter and render them on referencing Ingresses", func() {
+		server := newFakeNetBox("n3tb0x", []fakeNetBoxPrefix{
+			{prefix: "10.10.0.0/16", tags: []string{"office", "vpn"}, site: "oslo"},
+			{prefix: "10.20.0.0/16", tags: []string{"office"}, site: "bergen"},
+			{prefix: "10.30.0.0/16", tags: []string{"office"}, site: "tromso"},
+			{prefix: "10.40.0.0/16", tags: []string{"datacenter"}, site: "oslo"},
+		})
+		defer server.Close()
+
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "netbox-token", Namespace: "default"},
+			Data:       map[string][]byte{"token": []byte("n3tb0x")},
+		}
+		Expect(k8sClient.Create(ctx, secret)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, secret)).To(Succeed()) }()
+
+		source := &ingressnetworkpoliciesv1.NetBoxPrefixSource{
+			ObjectMeta: metav1.ObjectMeta{Name: "offices", Namespace: "default"},
+			Spec: ingressnetworkpoliciesv1.NetBoxPrefixSourceSpec{
+				URL:            server.URL + "/",
+				TokenSecretRef: ingressnetworkpoliciesv1.SecretAuthReference{Name: "netbox-token"},
+				Tags:           []string{"office"},
+				Sites:          []string{"oslo", "bergen"},
+			},
+		}
+		Expect(k8sClient.Create(ctx, source)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, source)).To(Succeed()) }()
+
+		sourceKey := types.NamespacedName{Name: source.Name, Namespace: source.Namespace}
+		sourceReconciler := &NetBoxPrefixSourceReconciler{Client: k8sClient, Scheme: k8sClient.Scheme(), HTTPClient: server.Client()}
+		_, err := sourceReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: sourceKey})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(k8sClient.Get(ctx, sourceKey, source)).To(Succeed())
+		Expect(meta.IsStatusConditionTrue(source.Status.Conditions, ingressnetworkpoliciesv1.CIDRSourceConditionReady)).To(BeTrue())
+		Expect(source.Status.Prefixes).To(Equal([]string{"10.10.0.0/16", "10.20.0.0/16"}))
+
+		ingress := newTestIngress("netbox-app", map[string]string{
+			AnnotationWhiteListNetworkPolicy: "netbox:default/offices",
+		})
+		Expect(k8sClient.Create(ctx, ingress)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, ingress)).To(Succeed()) }()
+
+		ingressKey := types.NamespacedName{Name: ingress.Name, Namespace: ingress.Namespace}
+		ingressReconciler := &IngressReconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+		_, err = ingressReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: ingressKey})
+		Expect(err).NotTo(HaveOccurred())
+
+		updated := &networkingv1.Ingress{}
+		Expect(k8sClient.Get(ctx, ingressKey, updated)).To(Succeed())
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxWhitelist, "10.10.0.0/16,10.20.0.0/16"))
+		Expect(ingressReconciler.ingressesForSource(SourceNetBox)(ctx, source)).To(ConsistOf(
+			reconcile.Request{NamespacedName: ingressKey},
+		))
+	})
+
+	It("should fail without the token and not follow pages on other hosts", func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"next":"http://attacker.example.com/api/ipam/prefixes/?offset=1","results":[{"prefix":"10.0.0.0/8"}]}`))
+		}))
+		defer server.Close()
+
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "netbox-other", Namespace: "default"},
+			Data:       map[string][]byte{"password": []byte("n3tb0x")},
+		}
+		Expect(k8sClient.Create(ctx, secret)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, secret)).To(Succeed()) }()
+
+		source := &ingressnetworkpoliciesv1.NetBoxPrefixSource{
+			ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "default"},
+			Spec: ingressnetworkpoliciesv1.NetBoxPrefixSourceSpec{
+				URL:            server.URL,
+				TokenSecretRef: ingressnetworkpoliciesv1.SecretAuthReference{Name: "netbox-other"},
+			},
+		}
+		_, err := fetchNetBoxPrefixes(ctx, k8sClient, server.Client(), source)
+		Expect(err).To(MatchError(ContainSubstring("has no token")))
+
+		secret.Data = map[string][]byte{"token": []byte("n3tb0x")}
+		Expect(k8sClient.Update(ctx, secret)).To(Succeed())
+		_, err = fetchNetBoxPrefixes(ctx, k8sClient, server.Client(), source)
+		Expect(err).To(MatchError(ContainSubstring("refusing to follow next page")))
+	})
+})
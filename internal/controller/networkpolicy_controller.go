@@ -24,8 +24,8 @@ import (
 	v1 "k8s.io/api/networking/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-	"sigs.k8s.io/controller-runtime/pkg/event"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 )
@@ -34,6 +34,7 @@ import (
 type NetworkPolicyReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
+	Access AccessConfig
 }
 
 // +kubebuilder:rbac:groups="networking.k8s.io",resources=networkpolicies,verbs=get;list;watch
@@ -52,11 +53,16 @@ type NetworkPolicyReconciler struct {
 func (r *NetworkPolicyReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	log := logf.FromContext(ctx)
 
-	// Fetch NetworkPolicy that triggered this reconciliation
+	// Fetch NetworkPolicy that triggered this reconciliation.
+	// A deleted NetworkPolicy still has to be removed from the Ingresses using it.
 	var triggeredNetworkPolicy v1.NetworkPolicy
 	if err := r.Get(ctx, req.NamespacedName, &triggeredNetworkPolicy); err != nil {
-		log.Error(err, "unable to fetch NetworkPolicy")
-		return ctrl.Result{}, client.IgnoreNotFound(err)
+		if client.IgnoreNotFound(err) != nil {
+			log.Error(err, "unable to fetch NetworkPolicy")
+			return ctrl.Result{}, err
+		}
+		triggeredNetworkPolicy.Namespace = req.Namespace
+		triggeredNetworkPolicy.Name = req.Name
 	}
 
 	log.Info("Reconciling Network Policy", "NetworkPolicy.Namespace", triggeredNetworkPolicy.Namespace, "NetworkPolicy.Name", triggeredNetworkPolicy.Name)
@@ -70,6 +76,21 @@ func (r *NetworkPolicyReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 		return ctrl.Result{}, err
 	}
 
+	// NetworkPolicies referenced by a MandatoryDenylist or LockdownPolicy apply to every managed Ingress
+	clusterPolicy := triggeredNetworkPolicy.Namespace == DefaultNamespace && clusterPoliciesUse(ctx, r.Client, func(annotations map[string]string) bool {
+		return slices.ContainsFunc(ingressReferenceAnnotations, func(annotation string) bool {
+			return slices.Contains(policyReferences(annotations[annotation]), triggeredNetworkPolicy.Name)
+		})
+	})
+
+	// Ingresses inheriting from the NetworkPolicies selecting their backend pods, canaries inherit from their primary Ingress
+	inheriting := map[client.ObjectKey]bool{}
+	for _, ingress := range allIngresses.Items {
+		if ingressInheritsBackendPolicies(&ingress) {
+			inheriting[client.ObjectKeyFromObject(&ingress)] = true
+		}
+	}
+
 	// Iterate through all Ingress and find ingress that reference the NetworkPolicy
 	for _, ingress := range allIngresses.Items {
 
@@ -80,23 +101,39 @@ func (r *NetworkPolicyReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 			continue
 		}
 
-		if _, exists := annotation[AnnotationWhiteListNetworkPolicy]; exists {
-			annotationList := filterSliceFromString(strings.Split(annotation[AnnotationWhiteListNetworkPolicy], ","))
-			if slices.Contains(annotationList, triggeredNetworkPolicy.Name) {
-				matchedIngresses = append(matchedIngresses, ingress)
-				found = true
+		// NetworkPolicies in the default namespace are referenced by name
+		if triggeredNetworkPolicy.Namespace == DefaultNamespace {
+			if _, exists := annotation[AnnotationWhiteListNetworkPolicy]; exists {
+				annotationList := policyReferences(annotation[AnnotationWhiteListNetworkPolicy])
+				found = found || slices.Contains(annotationList, triggeredNetworkPolicy.Name)
 			}
-		}
 
-		if _, exists := annotation[AnnotationDenyListNetworkPolicy]; exists {
-			annotationList := filterSliceFromString(strings.Split(annotation[AnnotationDenyListNetworkPolicy], ","))
-			if slices.Contains(annotationList, triggeredNetworkPolicy.Name) {
-				matchedIngresses = append(matchedIngresses, ingress)
-				found = true
+			if _, exists := annotation[AnnotationDenyListNetworkPolicy]; exists {
+				annotationList := policyReferences(annotation[AnnotationDenyListNetworkPolicy])
+				found = found || slices.Contains(annotationList, triggeredNetworkPolicy.Name)
+			}
+
+			// NetworkPolicies referenced by the defaults of the Ingress namespace
+			found = found || slices.Contains(policyReferences(annotation[AnnotationAppliedDefaults]), triggeredNetworkPolicy.Name)
+
+			// NetworkPolicies entering the selection match the selector, those leaving it were selected before
+			found = found || policySelectorMatches(annotation[AnnotationWhiteListPolicySelector], &triggeredNetworkPolicy)
+			found = found || policySelectorMatches(annotation[AnnotationDenyListPolicySelector], &triggeredNetworkPolicy)
+			for _, selected := range []string{AnnotationSelectedWhitelist, AnnotationSelectedDenylist} {
+				found = found || slices.Contains(filterSliceFromString(strings.Split(annotation[selected], ",")), triggeredNetworkPolicy.Name)
 			}
 		}
 
+		found = found || (clusterPolicy && ingressManaged(&ingress))
+
+		// NetworkPolicies in the Ingress namespace may select the backend pods of the Ingress
+		if ingress.Namespace == triggeredNetworkPolicy.Namespace && (inheriting[client.ObjectKeyFromObject(&ingress)] ||
+			inheriting[client.ObjectKey{Namespace: ingress.Namespace, Name: annotation[AnnotationCanaryOf]}]) {
+			found = true
+		}
+
 		if found {
+			matchedIngresses = append(matchedIngresses, ingress)
 			log.Info("Matched Ingress found for NetworkPolicy", "Ingress.Name", ingress.Name, "NetworkPolicy.Name", triggeredNetworkPolicy.Name)
 		}
 	}
@@ -111,60 +148,11 @@ func (r *NetworkPolicyReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 	// Update annotation on matched Ingress with CIDRs from NetworkPolicies
 	for _, ingress := range matchedIngresses {
 
-		// Get Annotations from Ingress
-		AnnotationWhiteListNetworkPolicy := ingress.GetAnnotations()[AnnotationWhiteListNetworkPolicy]
-		AnnotationDenyListNetworkPolicy := ingress.GetAnnotations()[AnnotationDenyListNetworkPolicy]
-		annotationWhitelist := ingress.GetAnnotations()[AnnotationWhitelist]
-		AnnotationDenylist := ingress.GetAnnotations()[AnnotationDenylist]
-
-		// Create slices from annotations
-		sliceWhitelistNetworkPolicy := filterSliceFromString(strings.Split(AnnotationWhiteListNetworkPolicy, ","))
-		sliceDenyListNetworkPolicy := filterSliceFromString(strings.Split(AnnotationDenyListNetworkPolicy, ","))
-		sliceWhitelist := filterSliceFromString(strings.Split(annotationWhitelist, ","))
-		sliceDenylist := filterSliceFromString(strings.Split(AnnotationDenylist, ","))
-
-		// Create CIDR Lists
-		var cidrWhitelist []string
-		var cidrDenylist []string
-
-		if len(sliceWhitelistNetworkPolicy) > 0 || len(sliceWhitelist) > 0 {
-			cidrWhitelist = createCidrList(ctx, r, ingress, sliceWhitelistNetworkPolicy, sliceWhitelist)
-		}
-
-		if len(sliceDenyListNetworkPolicy) > 0 || len(sliceDenylist) > 0 {
-			cidrDenylist = createCidrList(ctx, r, ingress, sliceDenyListNetworkPolicy, sliceDenylist)
-		}
-
-		// Update Ingress Annotations
-
-		if len(cidrWhitelist) > 0 {
-			ingress.Annotations[AnnotationNginxWhitelist] = strings.Join(cidrWhitelist, ",")
-		} else {
-			delete(ingress.Annotations, AnnotationNginxWhitelist)
-		}
-
-		if len(cidrDenylist) > 0 {
-			ingress.Annotations[AnnotationNginxDenylist] = strings.Join(cidrDenylist, ",")
-		} else {
-			delete(ingress.Annotations, AnnotationNginxDenylist)
-		}
-
-		// Validate annotations before updating
-		err := validateAnnotations(ingress.Annotations)
-		if err != nil {
-			log.Error(err, "invalid annotations for Ingress", "Ingress.Name", ingress.Name)
-			return ctrl.Result{}, err
-		}
-
-		// Update Ingress
-		if err := r.Update(ctx, &ingress); err != nil {
-			log.Error(err, "unable to remove Ingress annotation", "Ingress.Name", ingress.Name)
+		// Compute and render the access lists for the Ingress
+		// Refreshes are scheduled by the Ingress reconciler
+		if _, err := updateIngressAccess(ctx, r.Client, r.Scheme, r.Access, &ingress); err != nil {
 			return ctrl.Result{}, err
 		}
-
-		// Log successful update
-		log.Info("Updated Ingress annotation", "Ingress.Name", ingress.Name)
-
 	}
 
 	return ctrl.Result{}, nil
@@ -173,22 +161,26 @@ func (r *NetworkPolicyReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 // SetupWithManager sets up the controller with the Manager.
 func (r *NetworkPolicyReconciler) SetupWithManager(mgr ctrl.Manager) error {
 
-	// Predicate that filters updates where only annotations changed
-	annotationChangedPredicate := predicate.Funcs{
-		UpdateFunc: func(e event.UpdateEvent) bool {
-			return e.ObjectNew.GetNamespace() == DefaultNamespace
-		},
-		CreateFunc: func(e event.CreateEvent) bool {
-			return e.Object.GetNamespace() == DefaultNamespace
-		},
-		DeleteFunc: func(e event.DeleteEvent) bool {
-			return false
-		},
-	}
-
+	// NetworkPolicies outside the default namespace are only watched in namespaces with Ingresses
+	// inheriting from the NetworkPolicies selecting their backend pods
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&v1.NetworkPolicy{}).
-		WithEventFilter(annotationChangedPredicate).
+		For(&v1.NetworkPolicy{}, builder.WithPredicates(predicate.NewPredicateFuncs(r.networkPolicyUsable))).
 		Named("networkpolicy").
 		Complete(r)
 }
+
+// networkPolicyUsable reports whether Ingresses may use the NetworkPolicy, because it is in the default namespace,
+// or in a namespace with Ingresses inheriting from the NetworkPolicies selecting their backend pods.
+func (r *NetworkPolicyReconciler) networkPolicyUsable(obj client.Object) bool {
+	if obj.GetNamespace() == DefaultNamespace {
+		return true
+	}
+
+	ingressList := v1.IngressList{}
+	if err := r.List(context.Background(), &ingressList, client.InNamespace(obj.GetNamespace())); err != nil {
+		return true
+	}
+	return slices.ContainsFunc(ingressList.Items, func(ingress v1.Ingress) bool {
+		return ingressInheritsBackendPolicies(&ingress)
+	})
+}
@@ -0,0 +1,64 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// errUnauthorizedSource is returned for sources the namespace of the Ingress may not reference.
+var errUnauthorizedSource = errors.New("not authorized for the namespace")
+
+// sourceAuthorized reports whether Ingresses in the namespace may reference the shared source,
+// a NetworkPolicy or a typed source like a Secret or CIDRFeed, by the authorization annotations of the source.
+// Sources without authorization annotations may be referenced from every namespace.
+func sourceAuthorized(ctx context.Context, r Getter, source client.Object, namespace string) (bool, error) {
+	if namespace == source.GetNamespace() {
+		return true, nil
+	}
+
+	allowedNamespaces := filterSliceFromString(strings.Split(source.GetAnnotations()[AnnotationAllowedNamespaces], ","))
+	namespaceSelector := strings.TrimSpace(source.GetAnnotations()[AnnotationAllowedSelector])
+
+	if len(allowedNamespaces) == 0 && namespaceSelector == "" {
+		return true, nil
+	}
+
+	if slices.Contains(allowedNamespaces, namespace) {
+		return true, nil
+	}
+
+	if namespaceSelector == "" {
+		return false, nil
+	}
+
+	selector, err := labels.Parse(namespaceSelector)
+	if err != nil {
+		return false, fmt.Errorf("invalid namespace selector %q on %s: %w", namespaceSelector, client.ObjectKeyFromObject(source), err)
+	}
+
+	ns := corev1.Namespace{}
+	if err := r.Get(ctx, client.ObjectKey{Name: namespace}, &ns); err != nil {
+		return false, err
+	}
+
+	return selector.Matches(labels.Set(ns.Labels)), nil
+}
+
+// authorizeSource returns errUnauthorizedSource when Ingresses in the namespace may not reference the source.
+func authorizeSource(ctx context.Context, r Getter, source client.Object, namespace string) error {
+	authorized, err := sourceAuthorized(ctx, r, source, namespace)
+	if err != nil {
+		return err
+	}
+	if !authorized {
+		return fmt.Errorf("%s %w %s", client.ObjectKeyFromObject(source), errUnauthorizedSource, namespace)
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	ingressnetworkpoliciesv1 "github.com/vitistack/ingressnetworkpolicy-operator/api/v1"
+)
+
+var _ = Describe("Policy authorization", func() {
+	ctx := context.Background()
+
+	It("should authorize namespaces by list and selector", func() {
+		partner := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "partner-team", Labels: map[string]string{"partner-access": "true"}}}
+		Expect(client.IgnoreAlreadyExists(k8sClient.Create(ctx, partner))).To(Succeed())
+
+		open := newTestPolicy("open", nil, "10.0.0.0/24")
+		Expect(sourceAuthorized(ctx, k8sClient, open, "anyone")).To(BeTrue())
+
+		listed := newTestPolicy("listed", map[string]string{AnnotationAllowedNamespaces: "team-a, team-b"}, "10.0.0.0/24")
+		Expect(sourceAuthorized(ctx, k8sClient, listed, "team-b")).To(BeTrue())
+		Expect(sourceAuthorized(ctx, k8sClient, listed, "partner-team")).To(BeFalse())
+		Expect(sourceAuthorized(ctx, k8sClient, listed, DefaultNamespace)).To(BeTrue())
+
+		selected := newTestPolicy("selected", map[string]string{AnnotationAllowedSelector: "partner-access=true"}, "10.0.0.0/24")
+		Expect(sourceAuthorized(ctx, k8sClient, selected, "partner-team")).To(BeTrue())
+		ensureNamespace(ctx, "team-a")
+		Expect(sourceAuthorized(ctx, k8sClient, selected, "team-a")).To(BeFalse())
+	})
+
+	It("should refuse unauthorized references with an event and follow authorization changes", func() {
+		ensureNamespace(ctx, DefaultNamespace)
+
+		vpn := newTestPolicy("partner-vpn", map[string]string{AnnotationAllowedNamespaces: "team-a"}, "10.70.0.0/24")
+		office := newTestPolicy("office-ranges", nil, "10.71.0.0/24")
+		Expect(k8sClient.Create(ctx, vpn)).To(Succeed())
+		Expect(k8sClient.Create(ctx, office)).To(Succeed())
+		defer func() {
+			Expect(k8sClient.Delete(ctx, vpn)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, office)).To(Succeed())
+		}()
+
+		ingress := newTestIngress("authorized-app", map[string]string{
+			AnnotationWhiteListNetworkPolicy: "partner-vpn,office-ranges",
+		})
+		Expect(k8sClient.Create(ctx, ingress)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, ingress)).To(Succeed()) }()
+
+		recorder := record.NewFakeRecorder(10)
+		ingressKey := types.NamespacedName{Name: ingress.Name, Namespace: ingress.Namespace}
+		access := AccessConfig{Recorder: recorder}
+		ingressReconciler := &IngressReconciler{Client: k8sClient, Scheme: k8sClient.Scheme(), Access: access}
+		updated := reconcileAndGet(ingressReconciler, ingressKey)
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxWhitelist, "10.71.0.0/24"))
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationUnauthorizedPolicies, "partner-vpn"))
+		Expect(recorder.Events).To(Receive(ContainSubstring(EventReasonUnauthorizedPolicy)))
+
+		// An Ingress only referencing unauthorized policies is not opened
+		unauthorized := newTestIngress("unauthorized-app", map[string]string{AnnotationWhiteListNetworkPolicy: "partner-vpn"})
+		Expect(k8sClient.Create(ctx, unauthorized)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, unauthorized)).To(Succeed()) }()
+		unauthorized = reconcileAndGet(ingressReconciler, client.ObjectKeyFromObject(unauthorized))
+		Expect(unauthorized.Annotations).To(HaveKeyWithValue(AnnotationNginxWhitelist, "0.0.0.0/32,::/128"))
+
+		// Authorizing the namespace recomputes the Ingresses referencing the policy
+		vpn.Annotations[AnnotationAllowedNamespaces] = "team-a," + ingress.Namespace
+		Expect(k8sClient.Update(ctx, vpn)).To(Succeed())
+
+		policyReconciler := &NetworkPolicyReconciler{Client: k8sClient, Scheme: k8sClient.Scheme(), Access: access}
+		_, err := policyReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(vpn)})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(k8sClient.Get(ctx, ingressKey, updated)).To(Succeed())
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxWhitelist, "10.70.0.0/24,10.71.0.0/24"))
+		Expect(updated.Annotations).NotTo(HaveKey(AnnotationUnauthorizedPolicies))
+	})
+	It("should authorize typed sources in the operator namespace like NetworkPolicies", func() {
+		ensureNamespace(ctx, DefaultNamespace)
+
+		restricted := map[string]string{AnnotationAllowedNamespaces: "team-a"}
+		configMap := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "partner-vpn-ranges", Namespace: DefaultNamespace, Annotations: restricted},
+			Data:       map[string]string{"ranges": "10.72.0.0/24"},
+		}
+		set := &ingressnetworkpoliciesv1.CIDRSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "partner-vpn-ranges", Namespace: DefaultNamespace, Annotations: restricted},
+			Spec:       ingressnetworkpoliciesv1.CIDRSetSpec{Entries: []ingressnetworkpoliciesv1.CIDRSetEntry{{CIDR: "10.73.0.0/24"}}},
+		}
+		Expect(k8sClient.Create(ctx, configMap)).To(Succeed())
+		Expect(k8sClient.Create(ctx, set)).To(Succeed())
+		defer func() {
+			Expect(k8sClient.Delete(ctx, configMap)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, set)).To(Succeed())
+		}()
+
+		configMapReference := "configmap:" + DefaultNamespace + "/partner-vpn-ranges/ranges"
+		setReference := "cidrset:" + DefaultNamespace + "/partner-vpn-ranges"
+		ingress := newTestIngress("authorized-sources-app", map[string]string{
+			AnnotationWhiteListNetworkPolicy: configMapReference + "," + setReference,
+		})
+		Expect(k8sClient.Create(ctx, ingress)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, ingress)).To(Succeed()) }()
+
+		recorder := record.NewFakeRecorder(10)
+		ingressKey := client.ObjectKeyFromObject(ingress)
+		ingressReconciler := &IngressReconciler{Client: k8sClient, Scheme: k8sClient.Scheme(), Access: AccessConfig{Recorder: recorder}}
+		updated := reconcileAndGet(ingressReconciler, ingressKey)
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxWhitelist, "0.0.0.0/32,::/128"))
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationUnauthorizedPolicies, setReference+","+configMapReference))
+		Expect(recorder.Events).To(Receive(ContainSubstring(EventReasonUnauthorizedPolicy)))
+
+		// Authorizing the namespace on the source recomputes the Ingresses referencing it
+		configMap.Annotations[AnnotationAllowedNamespaces] = "team-a," + ingress.Namespace
+		Expect(k8sClient.Update(ctx, configMap)).To(Succeed())
+		Expect(ingressReconciler.ingressesForSource(SourceConfigMap)(ctx, configMap)).To(ConsistOf(reconcile.Request{NamespacedName: ingressKey}))
+
+		updated = reconcileAndGet(ingressReconciler, ingressKey)
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxWhitelist, "10.72.0.0/24"))
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationUnauthorizedPolicies, setReference))
+	})
+})
@@ -0,0 +1,78 @@
+package controller
+
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// policyPortsForIngress returns the ports policy rules must allow to be used for the Ingress.
+// It returns nil, meaning every rule is used, unless the Ingress opts in with the policy ports annotation.
+// The value "controller" selects the ports exposed by the ingress controller, configured on the IngressClass.
+func policyPortsForIngress(ingress *networkingv1.Ingress, ingressClass *networkingv1.IngressClass) []intstr.IntOrString {
+	value := strings.TrimSpace(ingress.GetAnnotations()[AnnotationPolicyPorts])
+	if value == "" {
+		return nil
+	}
+
+	if value == PolicyPortsController {
+		value = DefaultControllerPorts
+		if ingressClass != nil && strings.TrimSpace(ingressClass.GetAnnotations()[AnnotationControllerPorts]) != "" {
+			value = ingressClass.GetAnnotations()[AnnotationControllerPorts]
+		}
+	}
+
+	return parsePortList(value)
+}
+
+// parsePortList parses a comma separated list of port numbers and port names.
+func parsePortList(value string) []intstr.IntOrString {
+	ports := []intstr.IntOrString{}
+	for _, port := range filterSliceFromString(strings.Split(value, ",")) {
+		ports = append(ports, intstr.Parse(port))
+	}
+	return ports
+}
+
+// ruleMatchesPorts reports whether the ingress rule allows traffic to one of the ports.
+// Rules without ports allow every port, and a nil port list matches every rule.
+// Only TCP ports are matched, the protocol of the ingress controller.
+func ruleMatchesPorts(rule networkingv1.NetworkPolicyIngressRule, ports []intstr.IntOrString) bool {
+	if ports == nil || len(rule.Ports) == 0 {
+		return true
+	}
+
+	for _, rulePort := range rule.Ports {
+		if rulePort.Protocol != nil && *rulePort.Protocol != corev1.ProtocolTCP {
+			continue
+		}
+		if rulePort.Port == nil {
+			return true
+		}
+		for _, port := range ports {
+			if portMatches(rulePort, port) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// portMatches compares a port with a NetworkPolicy port, honoring port ranges.
+// Named ports only match the same name.
+func portMatches(rulePort networkingv1.NetworkPolicyPort, port intstr.IntOrString) bool {
+	if rulePort.Port.Type == intstr.String || port.Type == intstr.String {
+		return rulePort.Port.Type == port.Type && rulePort.Port.StrVal == port.StrVal
+	}
+
+	first := rulePort.Port.IntVal
+	last := first
+	if rulePort.EndPort != nil {
+		last = *rulePort.EndPort
+	}
+
+	return port.IntVal >= first && port.IntVal <= last
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+var _ = Describe("Policy ports", func() {
+	port := func(p intstr.IntOrString) networkingv1.NetworkPolicyPort {
+		return networkingv1.NetworkPolicyPort{Port: &p}
+	}
+
+	policy := &networkingv1.NetworkPolicy{
+		Spec: networkingv1.NetworkPolicySpec{
+			Ingress: []networkingv1.NetworkPolicyIngressRule{
+				{
+					Ports: []networkingv1.NetworkPolicyPort{port(intstr.FromInt32(443))},
+					From:  []networkingv1.NetworkPolicyPeer{{IPBlock: &networkingv1.IPBlock{CIDR: "10.0.0.0/24"}}},
+				},
+				{
+					Ports: []networkingv1.NetworkPolicyPort{port(intstr.FromInt32(8443))},
+					From:  []networkingv1.NetworkPolicyPeer{{IPBlock: &networkingv1.IPBlock{CIDR: "192.0.2.0/24"}}},
+				},
+				{
+					From: []networkingv1.NetworkPolicyPeer{{IPBlock: &networkingv1.IPBlock{CIDR: "10.9.0.0/24"}}},
+				},
+			},
+		},
+	}
+
+	It("should use every rule unless ports are requested", func() {
+		Expect(extractCIDRsFromNetworkPolicy(policy, nil, nil)).To(ConsistOf("10.0.0.0/24", "192.0.2.0/24", "10.9.0.0/24"))
+	})
+
+	It("should only use rules matching the ingress controller ports", func() {
+		ingress := newTestIngress("ports-app", map[string]string{AnnotationPolicyPorts: PolicyPortsController})
+
+		ports := policyPortsForIngress(ingress, nil)
+		Expect(extractCIDRsFromNetworkPolicy(policy, nil, ports)).To(ConsistOf("10.0.0.0/24", "10.9.0.0/24"))
+
+		ingressClass := &networkingv1.IngressClass{ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{AnnotationControllerPorts: "8443"},
+		}}
+		ports = policyPortsForIngress(ingress, ingressClass)
+		Expect(extractCIDRsFromNetworkPolicy(policy, nil, ports)).To(ConsistOf("192.0.2.0/24", "10.9.0.0/24"))
+	})
+
+	It("should match port ranges and named ports", func() {
+		endPort := int32(9000)
+		rangeRule := networkingv1.NetworkPolicyIngressRule{Ports: []networkingv1.NetworkPolicyPort{
+			{Port: &intstr.IntOrString{Type: intstr.Int, IntVal: 8000}, EndPort: &endPort},
+		}}
+		Expect(ruleMatchesPorts(rangeRule, parsePortList("8443"))).To(BeTrue())
+		Expect(ruleMatchesPorts(rangeRule, parsePortList("443"))).To(BeFalse())
+
+		namedRule := networkingv1.NetworkPolicyIngressRule{Ports: []networkingv1.NetworkPolicyPort{port(intstr.FromString("https"))}}
+		Expect(ruleMatchesPorts(namedRule, parsePortList("https"))).To(BeTrue())
+		Expect(ruleMatchesPorts(namedRule, parsePortList("443"))).To(BeFalse())
+	})
+
+	It("should only match TCP ports", func() {
+		udp, tcp := corev1.ProtocolUDP, corev1.ProtocolTCP
+		https := intstr.FromInt32(443)
+		udpRule := networkingv1.NetworkPolicyIngressRule{Ports: []networkingv1.NetworkPolicyPort{{Protocol: &udp, Port: &https}}}
+		Expect(ruleMatchesPorts(udpRule, parsePortList("443"))).To(BeFalse())
+
+		allUDPRule := networkingv1.NetworkPolicyIngressRule{Ports: []networkingv1.NetworkPolicyPort{{Protocol: &udp}}}
+		Expect(ruleMatchesPorts(allUDPRule, parsePortList("443"))).To(BeFalse())
+
+		tcpRule := networkingv1.NetworkPolicyIngressRule{Ports: []networkingv1.NetworkPolicyPort{{Protocol: &udp, Port: &https}, {Protocol: &tcp, Port: &https}}}
+		Expect(ruleMatchesPorts(tcpRule, parsePortList("443"))).To(BeTrue())
+	})
+})
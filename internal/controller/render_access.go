@@ -0,0 +1,143 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	v1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// accessRenderer writes the computed allow- and denylist of an Ingress in the format
+// understood by the ingress controller serving it.
+type accessRenderer interface {
+	name() string
+	render(ctx context.Context, c client.Client, scheme *runtime.Scheme, ingress *v1.Ingress, allow []string, deny []string) error
+	// allowlist returns the allowlist rendered for the Ingress, empty when none is rendered.
+	allowlist(ctx context.Context, c client.Client, ingress *v1.Ingress) ([]string, error)
+	// rendered reports whether the annotations of the Ingress reference lists rendered by the renderer.
+	rendered(ingress *v1.Ingress) bool
+}
+
+// accessRenderers are all renderers, so lists rendered by another renderer than the selected one can be removed.
+var accessRenderers = []accessRenderer{newNginxRenderer(nil), kongRenderer{}, apisixRenderer{}}
+
+// errNotOwned is returned when rendering would overwrite an object or annotation written by someone else.
+var errNotOwned = errors.New("not owned by the Ingress")
+
+// getIngressClass fetches the IngressClass of the Ingress.
+// It returns nil when the Ingress has no class or the class does not exist.
+func getIngressClass(ctx context.Context, c client.Client, ingress *v1.Ingress) (*v1.IngressClass, error) {
+	className := ingressClassName(ingress)
+	if className == "" {
+		return nil, nil
+	}
+
+	ingressClass := v1.IngressClass{}
+	if err := c.Get(ctx, client.ObjectKey{Name: className}, &ingressClass); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &ingressClass, nil
+}
+
+// ingressClassName returns the IngressClass name of the Ingress, preferring the spec over the legacy annotation.
+func ingressClassName(ingress *v1.Ingress) string {
+	if ingress.Spec.IngressClassName != nil {
+		return *ingress.Spec.IngressClassName
+	}
+	return ingress.GetAnnotations()[AnnotationIngressClass]
+}
+
+// selectAccessRenderer picks the renderer for the IngressClass.
+// The renderer annotation on the IngressClass takes precedence over the controller name,
+// and Ingresses without a known IngressClass fall back to the nginx renderer.
+func selectAccessRenderer(ingressClass *v1.IngressClass) accessRenderer {
+	if ingressClass == nil {
+		return newNginxRenderer(nil)
+	}
+
+	renderer := ingressClass.GetAnnotations()[AnnotationRenderer]
+	if renderer == "" {
+		switch ingressClass.Spec.Controller {
+		case IngressControllerKong:
+			renderer = RendererKong
+		case IngressControllerApisix:
+			renderer = RendererApisix
+		}
+	}
+
+	switch renderer {
+	case RendererKong:
+		return kongRenderer{}
+	case RendererApisix:
+		return apisixRenderer{}
+	default:
+		return newNginxRenderer(ingressClass)
+	}
+}
+
+// removeStaleRenderings removes the lists rendered for the Ingress by other renderers than the selected one,
+// f.ex after the renderer of its IngressClass changed.
+func removeStaleRenderings(ctx context.Context, c client.Client, scheme *runtime.Scheme, renderer accessRenderer, ingress *v1.Ingress) error {
+	for _, other := range accessRenderers {
+		if other.name() == renderer.name() || !other.rendered(ingress) {
+			continue
+		}
+		if err := other.render(ctx, c, scheme, ingress, nil, nil); err != nil {
+			return fmt.Errorf("unable to remove lists rendered by %s: %w", other.name(), err)
+		}
+	}
+	return nil
+}
+
+// getOwnedObject fetches obj, and reports whether it exists and is controlled by the Ingress.
+func getOwnedObject(ctx context.Context, c client.Client, ingress *v1.Ingress, obj client.Object) (bool, error) {
+	if err := c.Get(ctx, client.ObjectKeyFromObject(obj), obj); err != nil {
+		return false, client.IgnoreNotFound(err)
+	}
+	return metav1.IsControlledBy(obj, ingress), nil
+}
+
+// checkOwned refuses existing objects that are not controlled by the Ingress, so objects written by hand are never adopted.
+func checkOwned(ingress *v1.Ingress, obj client.Object) error {
+	if obj.GetResourceVersion() == "" || metav1.IsControlledBy(obj, ingress) {
+		return nil
+	}
+	return fmt.Errorf("refusing to overwrite %s %s/%s %w", obj.GetObjectKind().GroupVersionKind().Kind, obj.GetNamespace(), obj.GetName(), errNotOwned)
+}
+
+// accessPluginName returns the name of the plugin object owned by the Ingress.
+func accessPluginName(ingress *v1.Ingress) string {
+	return ingress.Name + "-ip-restriction"
+}
+
+// addToList adds item to the comma separated list if it is not already present.
+func addToList(list string, item string) string {
+	items := filterSliceFromString(strings.Split(list, ","))
+	for _, existing := range items {
+		if existing == item {
+			return strings.Join(items, ",")
+		}
+	}
+	return strings.Join(append(items, item), ",")
+}
+
+// removeFromList removes item from the comma separated list.
+func removeFromList(list string, item string) string {
+	var items []string
+	for _, existing := range filterSliceFromString(strings.Split(list, ",")) {
+		if existing != item {
+			items = append(items, existing)
+		}
+	}
+	return strings.Join(items, ",")
+}
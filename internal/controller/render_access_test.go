@@ -0,0 +1,388 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	ingressnetworkpoliciesv1 "github.com/vitistack/ingressnetworkpolicy-operator/api/v1"
+)
+
+// newTestIngress returns a minimal Ingress in the default namespace with the given annotations.
+func newTestIngress(name string, annotations map[string]string) *networkingv1.Ingress {
+	pathType := networkingv1.PathTypePrefix
+	return &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   "default",
+			Annotations: annotations,
+		},
+		Spec: networkingv1.IngressSpec{
+			Rules: []networkingv1.IngressRule{{
+				Host: name + ".example.com",
+				IngressRuleValue: networkingv1.IngressRuleValue{
+					HTTP: &networkingv1.HTTPIngressRuleValue{
+						Paths: []networkingv1.HTTPIngressPath{{
+							Path:     "/",
+							PathType: &pathType,
+							Backend: networkingv1.IngressBackend{
+								Service: &networkingv1.IngressServiceBackend{
+									Name: name,
+									Port: networkingv1.ServiceBackendPort{Number: 80},
+								},
+							},
+						}},
+					},
+				},
+			}},
+		},
+	}
+}
+
+// newTestPolicy returns a NetworkPolicy in the operator namespace with the given annotations,
+// allowing ingress from the CIDRs.
+func newTestPolicy(name string, annotations map[string]string, cidrs ...string) *networkingv1.NetworkPolicy {
+	var peers []networkingv1.NetworkPolicyPeer
+	for _, cidr := range cidrs {
+		peers = append(peers, networkingv1.NetworkPolicyPeer{IPBlock: &networkingv1.IPBlock{CIDR: cidr}})
+	}
+	return &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   DefaultNamespace,
+			Annotations: annotations,
+		},
+		Spec: networkingv1.NetworkPolicySpec{
+			Ingress: []networkingv1.NetworkPolicyIngressRule{{From: peers}},
+		},
+	}
+}
+
+// reconcileAndGet reconciles the Ingress and returns it as updated by the reconciler.
+func reconcileAndGet(reconciler *IngressReconciler, key client.ObjectKey) *networkingv1.Ingress {
+	ctx := context.Background()
+	_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+	Expect(err).NotTo(HaveOccurred())
+	updated := &networkingv1.Ingress{}
+	Expect(k8sClient.Get(ctx, key, updated)).To(Succeed())
+	return updated
+}
+
+// ensureNamespace creates the namespace unless it already exists.
+func ensureNamespace(ctx context.Context, name string) {
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	err := k8sClient.Create(ctx, namespace)
+	if err != nil && !errors.IsAlreadyExists(err) {
+		Expect(err).NotTo(HaveOccurred())
+	}
+}
+
+var _ = Describe("Access renderers", func() {
+	ctx := context.Background()
+
+	It("should add and remove plugin names without touching other plugins", func() {
+		Expect(addToList("cors, rate-limit", "app-ip-restriction")).To(Equal("cors,rate-limit,app-ip-restriction"))
+		Expect(addToList("app-ip-restriction", "app-ip-restriction")).To(Equal("app-ip-restriction"))
+		Expect(removeFromList("cors,app-ip-restriction", "app-ip-restriction")).To(Equal("cors"))
+		Expect(removeFromList("app-ip-restriction", "app-ip-restriction")).To(BeEmpty())
+	})
+
+	It("should select the renderer from the IngressClass", func() {
+		kongClass := &networkingv1.IngressClass{
+			ObjectMeta: metav1.ObjectMeta{Name: "kong"},
+			Spec:       networkingv1.IngressClassSpec{Controller: IngressControllerKong},
+		}
+		Expect(k8sClient.Create(ctx, kongClass)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, kongClass)).To(Succeed()) }()
+
+		ingress := newTestIngress("kong-app", nil)
+		className := "kong"
+		ingress.Spec.IngressClassName = &className
+
+		ingressClass, err := getIngressClass(ctx, k8sClient, ingress)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(selectAccessRenderer(ingressClass).name()).To(Equal(RendererKong))
+
+		missingClass := "missing"
+		ingress.Spec.IngressClassName = &missingClass
+		ingressClass, err = getIngressClass(ctx, k8sClient, ingress)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ingressClass).To(BeNil())
+		Expect(selectAccessRenderer(ingressClass).name()).To(Equal(RendererNginx))
+	})
+
+	It("should map a changed IngressClass to the managed Ingresses of the class", func() {
+		managed := newTestIngress("class-managed-app", map[string]string{AnnotationWhitelist: "10.0.0.0/24"})
+		managed.Spec.IngressClassName = ptr.To("class-watch")
+		legacy := newTestIngress("class-legacy-app", map[string]string{AnnotationWhitelist: "10.0.0.0/24", AnnotationIngressClass: "class-watch"})
+		unmanaged := newTestIngress("class-unmanaged-app", nil)
+		unmanaged.Spec.IngressClassName = ptr.To("class-watch")
+		other := newTestIngress("class-other-app", map[string]string{AnnotationWhitelist: "10.0.0.0/24"})
+		for _, ingress := range []*networkingv1.Ingress{managed, legacy, unmanaged, other} {
+			Expect(k8sClient.Create(ctx, ingress)).To(Succeed())
+			defer func() { Expect(k8sClient.Delete(ctx, ingress)).To(Succeed()) }()
+		}
+
+		reconciler := &IngressReconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+		ingressClass := &networkingv1.IngressClass{ObjectMeta: metav1.ObjectMeta{Name: "class-watch"}}
+		Expect(reconciler.ingressesOfClass(ctx, ingressClass)).To(ConsistOf(
+			reconcile.Request{NamespacedName: client.ObjectKeyFromObject(managed)},
+			reconcile.Request{NamespacedName: client.ObjectKeyFromObject(legacy)},
+		))
+	})
+
+	It("should render the nginx annotations from referenced NetworkPolicies", func() {
+		ensureNamespace(ctx, DefaultNamespace)
+
+		policy := &networkingv1.NetworkPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "office", Namespace: DefaultNamespace},
+			Spec: networkingv1.NetworkPolicySpec{
+				Ingress: []networkingv1.NetworkPolicyIngressRule{{
+					From: []networkingv1.NetworkPolicyPeer{
+						{IPBlock: &networkingv1.IPBlock{CIDR: "10.0.0.0/24"}},
+						{IPBlock: &networkingv1.IPBlock{CIDR: "10.0.1.0/24"}},
+					},
+				}},
+			},
+		}
+		Expect(k8sClient.Create(ctx, policy)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, policy)).To(Succeed()) }()
+
+		ingress := newTestIngress("nginx-app", map[string]string{
+			AnnotationWhiteListNetworkPolicy: "office",
+			AnnotationDenylist:               "10.0.0.7/32",
+		})
+		Expect(k8sClient.Create(ctx, ingress)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, ingress)).To(Succeed()) }()
+
+		controllerReconciler := &IngressReconciler{
+			Client: k8sClient,
+			Scheme: k8sClient.Scheme(),
+		}
+		_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+			NamespacedName: types.NamespacedName{Name: ingress.Name, Namespace: ingress.Namespace},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		updated := &networkingv1.Ingress{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: ingress.Name, Namespace: ingress.Namespace}, updated)).To(Succeed())
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxWhitelist, "10.0.0.0/24,10.0.1.0/24"))
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxDenylist, "10.0.0.7/32"))
+	})
+
+	Context("When rendering plugin objects", func() {
+		var kongClass, apisixClass *networkingv1.IngressClass
+		var reconciler *IngressReconciler
+
+		BeforeEach(func() {
+			reconciler = &IngressReconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+			kongClass = &networkingv1.IngressClass{
+				ObjectMeta: metav1.ObjectMeta{Name: "kong-plugins"},
+				Spec:       networkingv1.IngressClassSpec{Controller: IngressControllerKong},
+			}
+			apisixClass = &networkingv1.IngressClass{
+				ObjectMeta: metav1.ObjectMeta{Name: "apisix-plugins"},
+				Spec:       networkingv1.IngressClassSpec{Controller: IngressControllerApisix},
+			}
+			Expect(k8sClient.Create(ctx, kongClass)).To(Succeed())
+			Expect(k8sClient.Create(ctx, apisixClass)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			Expect(k8sClient.Delete(ctx, kongClass)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, apisixClass)).To(Succeed())
+		})
+
+		newPluginObject := func(gvk schema.GroupVersionKind, ingress *networkingv1.Ingress) *unstructured.Unstructured {
+			obj := &unstructured.Unstructured{}
+			obj.SetGroupVersionKind(gvk)
+			obj.SetNamespace(ingress.Namespace)
+			obj.SetName(accessPluginName(ingress))
+			return obj
+		}
+
+		It("should render a KongPlugin with the allow- and denylist, and remove it when switching renderer", func() {
+			ingress := newTestIngress("kong-plugin-app", map[string]string{
+				AnnotationWhitelist:   "10.0.0.0/24",
+				AnnotationDenylist:    "10.0.0.7/32",
+				AnnotationKongPlugins: "cors",
+			})
+			ingress.Spec.IngressClassName = &kongClass.Name
+			Expect(k8sClient.Create(ctx, ingress)).To(Succeed())
+			defer func() { Expect(k8sClient.Delete(ctx, ingress)).To(Succeed()) }()
+
+			updated := reconcileAndGet(reconciler, client.ObjectKeyFromObject(ingress))
+			Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationKongPlugins, "cors,kong-plugin-app-ip-restriction"))
+			Expect(updated.Annotations).NotTo(HaveKey(AnnotationNginxWhitelist))
+
+			plugin := newPluginObject(kongPluginGVK, ingress)
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(plugin), plugin)).To(Succeed())
+			Expect(metav1.IsControlledBy(plugin, updated)).To(BeTrue())
+			Expect(plugin.Object).To(HaveKeyWithValue("plugin", "ip-restriction"))
+			allow, _, err := unstructured.NestedStringSlice(plugin.Object, "config", "allow")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(allow).To(Equal([]string{"10.0.0.0/24"}))
+			deny, _, err := unstructured.NestedStringSlice(plugin.Object, "config", "deny")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(deny).To(Equal([]string{"10.0.0.7/32"}))
+
+			// Moving the Ingress to a class rendered by nginx removes the plugin and its reference
+			updated.Spec.IngressClassName = nil
+			Expect(k8sClient.Update(ctx, updated)).To(Succeed())
+			updated = reconcileAndGet(reconciler, client.ObjectKeyFromObject(updated))
+			Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationKongPlugins, "cors"))
+			Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxWhitelist, "10.0.0.0/24"))
+			Expect(errors.IsNotFound(k8sClient.Get(ctx, client.ObjectKeyFromObject(plugin), plugin))).To(BeTrue())
+		})
+
+		It("should render an ApisixPluginConfig with the denylist subtracted, and remove it when switching renderer", func() {
+			mandatory := &ingressnetworkpoliciesv1.MandatoryDenylist{
+				ObjectMeta: metav1.ObjectMeta{Name: "apisix-security"},
+				Spec:       ingressnetworkpoliciesv1.MandatoryDenylistSpec{Entries: []string{"10.0.0.0/26"}},
+			}
+			Expect(k8sClient.Create(ctx, mandatory)).To(Succeed())
+			defer func() { Expect(k8sClient.Delete(ctx, mandatory)).To(Succeed()) }()
+
+			ingress := newTestIngress("apisix-plugin-app", map[string]string{
+				AnnotationWhitelist: "10.0.0.0/24",
+				AnnotationDenylist:  "10.0.0.128/25",
+			})
+			ingress.Spec.IngressClassName = &apisixClass.Name
+			Expect(k8sClient.Create(ctx, ingress)).To(Succeed())
+			defer func() { Expect(k8sClient.Delete(ctx, ingress)).To(Succeed()) }()
+
+			updated := reconcileAndGet(reconciler, client.ObjectKeyFromObject(ingress))
+			Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationApisixPluginConfig, "apisix-plugin-app-ip-restriction"))
+
+			// Both the denylist of the Ingress and the mandatory denylist are subtracted, since ip-restriction can't combine them
+			pluginConfig := newPluginObject(apisixPluginConfigGVK, ingress)
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(pluginConfig), pluginConfig)).To(Succeed())
+			Expect(metav1.IsControlledBy(pluginConfig, updated)).To(BeTrue())
+			plugins, _, err := unstructured.NestedSlice(pluginConfig.Object, "spec", "plugins")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(plugins).To(HaveLen(1))
+			whitelist, _, err := unstructured.NestedStringSlice(plugins[0].(map[string]any), "config", "whitelist")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(whitelist).To(Equal([]string{"10.0.0.64/26"}))
+			_, blacklisted, err := unstructured.NestedStringSlice(plugins[0].(map[string]any), "config", "blacklist")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(blacklisted).To(BeFalse())
+
+			// Moving the Ingress to Kong replaces the plugin config with a KongPlugin
+			updated.Spec.IngressClassName = &kongClass.Name
+			Expect(k8sClient.Update(ctx, updated)).To(Succeed())
+			updated = reconcileAndGet(reconciler, client.ObjectKeyFromObject(updated))
+			Expect(updated.Annotations).NotTo(HaveKey(AnnotationApisixPluginConfig))
+			Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationKongPlugins, "apisix-plugin-app-ip-restriction"))
+			Expect(errors.IsNotFound(k8sClient.Get(ctx, client.ObjectKeyFromObject(pluginConfig), pluginConfig))).To(BeTrue())
+		})
+
+		It("should never adopt a plugin written by hand and keep the current access without retrying", func() {
+			ingress := newTestIngress("kong-manual-app", map[string]string{
+				AnnotationWhitelist: "10.0.0.0/24",
+			})
+			ingress.Spec.IngressClassName = &kongClass.Name
+			Expect(k8sClient.Create(ctx, ingress)).To(Succeed())
+			defer func() { Expect(k8sClient.Delete(ctx, ingress)).To(Succeed()) }()
+
+			manual := newPluginObject(kongPluginGVK, ingress)
+			manual.Object["plugin"] = "ip-restriction"
+			manual.Object["config"] = map[string]any{"allow": []any{"192.168.0.0/16"}}
+			Expect(k8sClient.Create(ctx, manual)).To(Succeed())
+			defer func() { Expect(k8sClient.Delete(ctx, manual)).To(Succeed()) }()
+
+			recorder := record.NewFakeRecorder(10)
+			manualReconciler := &IngressReconciler{Client: k8sClient, Scheme: k8sClient.Scheme(), Access: AccessConfig{Recorder: recorder}}
+			result, err := manualReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(ingress)})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(BeZero())
+			Expect(recorder.Events).To(Receive(ContainSubstring(EventReasonNotOwned)))
+
+			updated := &networkingv1.Ingress{}
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(ingress), updated)).To(Succeed())
+			Expect(updated.Annotations).NotTo(HaveKey(AnnotationKongPlugins))
+
+			plugin := newPluginObject(kongPluginGVK, ingress)
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(plugin), plugin)).To(Succeed())
+			Expect(plugin.GetOwnerReferences()).To(BeEmpty())
+			allow, _, err := unstructured.NestedStringSlice(plugin.Object, "config", "allow")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(allow).To(Equal([]string{"192.168.0.0/16"}))
+
+			// Removing the lists leaves the plugin written by hand in place
+			delete(updated.Annotations, AnnotationWhitelist)
+			updated.Annotations[AnnotationKongPlugins] = plugin.GetName()
+			Expect(k8sClient.Update(ctx, updated)).To(Succeed())
+			reconcileAndGet(manualReconciler, client.ObjectKeyFromObject(ingress))
+			Expect(recorder.Events).To(Receive(ContainSubstring(EventReasonNotOwned)))
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(plugin), plugin)).To(Succeed())
+		})
+	})
+
+	Context("When rendering nginx annotations", func() {
+		allowlistClass := &networkingv1.IngressClass{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "nginx",
+				Annotations: map[string]string{
+					AnnotationNginxAllowlistKey: "nginx.ingress.kubernetes.io/allowlist-source-range",
+				},
+			},
+		}
+
+		It("should keep writing the legacy key it owns until migration is enabled", func() {
+			ingress := newTestIngress("legacy-app", map[string]string{
+				AnnotationWhitelist:      "10.1.0.0/24",
+				AnnotationNginxWhitelist: "10.0.0.0/24",
+			})
+
+			Expect(newNginxRenderer(allowlistClass).render(ctx, nil, nil, ingress, []string{"10.1.0.0/24"}, nil)).To(Succeed())
+			Expect(ingress.Annotations).To(HaveKeyWithValue(AnnotationNginxWhitelist, "10.1.0.0/24"))
+			Expect(ingress.Annotations).To(HaveKeyWithValue("nginx.ingress.kubernetes.io/allowlist-source-range", "10.1.0.0/24"))
+
+			migratingClass := allowlistClass.DeepCopy()
+			migratingClass.Annotations[AnnotationNginxKeyMigration] = "true"
+
+			Expect(newNginxRenderer(migratingClass).render(ctx, nil, nil, ingress, []string{"10.1.0.0/24"}, nil)).To(Succeed())
+			Expect(ingress.Annotations).NotTo(HaveKey(AnnotationNginxWhitelist))
+			Expect(ingress.Annotations).To(HaveKeyWithValue("nginx.ingress.kubernetes.io/allowlist-source-range", "10.1.0.0/24"))
+			Expect(ingress.Annotations).To(HaveKeyWithValue(AnnotationManagedAllowlistKeys, "nginx.ingress.kubernetes.io/allowlist-source-range"))
+		})
+
+		It("should refuse to overwrite a key it does not own", func() {
+			ingress := newTestIngress("foreign-app", map[string]string{
+				"nginx.ingress.kubernetes.io/allowlist-source-range": "192.0.2.0/24",
+			})
+
+			Expect(newNginxRenderer(allowlistClass).render(ctx, nil, nil, ingress, []string{"10.1.0.0/24"}, nil)).NotTo(Succeed())
+			Expect(ingress.Annotations).To(HaveKeyWithValue("nginx.ingress.kubernetes.io/allowlist-source-range", "192.0.2.0/24"))
+		})
+	})
+})
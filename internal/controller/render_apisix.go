@@ -0,0 +1,105 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	v1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// +kubebuilder:rbac:groups="apisix.apache.org",resources=apisixpluginconfigs,verbs=get;list;watch;create;update;patch;delete
+
+var apisixPluginConfigGVK = schema.GroupVersionKind{Group: "apisix.apache.org", Version: "v2", Kind: "ApisixPluginConfig"}
+
+// apisixRenderer writes the lists to an ip-restriction ApisixPluginConfig owned by the Ingress
+// and references the plugin config from the Ingress.
+type apisixRenderer struct{}
+
+func (apisixRenderer) name() string {
+	return RendererApisix
+}
+
+func (apisixRenderer) render(ctx context.Context, c client.Client, scheme *runtime.Scheme, ingress *v1.Ingress, allow []string, deny []string) error {
+	pluginConfig := &unstructured.Unstructured{}
+	pluginConfig.SetGroupVersionKind(apisixPluginConfigGVK)
+	pluginConfig.SetNamespace(ingress.Namespace)
+	pluginConfig.SetName(accessPluginName(ingress))
+
+	// Never take over a plugin config reference set by someone else
+	if current := ingress.Annotations[AnnotationApisixPluginConfig]; current != "" && current != pluginConfig.GetName() {
+		return fmt.Errorf("annotation %s already references plugin config %q", AnnotationApisixPluginConfig, current)
+	}
+
+	if len(allow) == 0 && len(deny) == 0 {
+		if err := deleteOwnedObject(ctx, c, ingress, pluginConfig); err != nil {
+			return err
+		}
+		delete(ingress.Annotations, AnnotationApisixPluginConfig)
+		return nil
+	}
+
+	// The APISIX ip-restriction plugin accepts either a whitelist or a blacklist,
+	// the denylist is subtracted from the allowlist before rendering
+	config := map[string]any{}
+	if len(allow) > 0 {
+		config["whitelist"] = toAnySlice(allow)
+	} else {
+		config["blacklist"] = toAnySlice(deny)
+	}
+
+	_, err := controllerutil.CreateOrUpdate(ctx, c, pluginConfig, func() error {
+		if err := checkOwned(ingress, pluginConfig); err != nil {
+			return err
+		}
+		pluginConfig.Object["spec"] = map[string]any{
+			"plugins": []any{
+				map[string]any{
+					"name":   "ip-restriction",
+					"enable": true,
+					"config": config,
+				},
+			},
+		}
+		return controllerutil.SetControllerReference(ingress, pluginConfig, scheme)
+	})
+	if err != nil {
+		return err
+	}
+
+	ingress.Annotations[AnnotationApisixPluginConfig] = pluginConfig.GetName()
+
+	return nil
+}
+
+func (apisixRenderer) rendered(ingress *v1.Ingress) bool {
+	return ingress.Annotations[AnnotationApisixPluginConfig] == accessPluginName(ingress)
+}
+
+func (apisixRenderer) allowlist(ctx context.Context, c client.Client, ingress *v1.Ingress) ([]string, error) {
+	pluginConfig := &unstructured.Unstructured{}
+	pluginConfig.SetGroupVersionKind(apisixPluginConfigGVK)
+	pluginConfig.SetNamespace(ingress.Namespace)
+	pluginConfig.SetName(accessPluginName(ingress))
+
+	if owned, err := getOwnedObject(ctx, c, ingress, pluginConfig); err != nil || !owned {
+		return nil, err
+	}
+	plugins, _, err := unstructured.NestedSlice(pluginConfig.Object, "spec", "plugins")
+	if err != nil {
+		return nil, err
+	}
+	for _, plugin := range plugins {
+		plugin, ok := plugin.(map[string]any)
+		if !ok || plugin["name"] != "ip-restriction" {
+			continue
+		}
+		whitelist, _, err := unstructured.NestedStringSlice(plugin, "config", "whitelist")
+		return whitelist, err
+	}
+	return nil, nil
+}
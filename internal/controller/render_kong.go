@@ -0,0 +1,118 @@
+package controller
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	v1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// +kubebuilder:rbac:groups="configuration.konghq.com",resources=kongplugins,verbs=get;list;watch;create;update;patch;delete
+
+var kongPluginGVK = schema.GroupVersionKind{Group: "configuration.konghq.com", Version: "v1", Kind: "KongPlugin"}
+
+// kongRenderer writes the lists to an ip-restriction KongPlugin owned by the Ingress
+// and references the plugin from the Ingress.
+type kongRenderer struct{}
+
+func (kongRenderer) name() string {
+	return RendererKong
+}
+
+func (kongRenderer) render(ctx context.Context, c client.Client, scheme *runtime.Scheme, ingress *v1.Ingress, allow []string, deny []string) error {
+	plugin := &unstructured.Unstructured{}
+	plugin.SetGroupVersionKind(kongPluginGVK)
+	plugin.SetNamespace(ingress.Namespace)
+	plugin.SetName(accessPluginName(ingress))
+
+	if len(allow) == 0 && len(deny) == 0 {
+		if err := deleteOwnedObject(ctx, c, ingress, plugin); err != nil {
+			return err
+		}
+		setOrDeleteAnnotation(ingress, AnnotationKongPlugins, removeFromList(ingress.Annotations[AnnotationKongPlugins], plugin.GetName()))
+		return nil
+	}
+
+	_, err := controllerutil.CreateOrUpdate(ctx, c, plugin, func() error {
+		if err := checkOwned(ingress, plugin); err != nil {
+			return err
+		}
+		config := map[string]any{}
+		if len(allow) > 0 {
+			config["allow"] = toAnySlice(allow)
+		}
+		if len(deny) > 0 {
+			config["deny"] = toAnySlice(deny)
+		}
+		plugin.Object["plugin"] = "ip-restriction"
+		plugin.Object["config"] = config
+		return controllerutil.SetControllerReference(ingress, plugin, scheme)
+	})
+	if err != nil {
+		return err
+	}
+
+	ingress.Annotations[AnnotationKongPlugins] = addToList(ingress.Annotations[AnnotationKongPlugins], plugin.GetName())
+
+	return nil
+}
+
+func (kongRenderer) allowlist(ctx context.Context, c client.Client, ingress *v1.Ingress) ([]string, error) {
+	plugin := &unstructured.Unstructured{}
+	plugin.SetGroupVersionKind(kongPluginGVK)
+	plugin.SetNamespace(ingress.Namespace)
+	plugin.SetName(accessPluginName(ingress))
+
+	if owned, err := getOwnedObject(ctx, c, ingress, plugin); err != nil || !owned {
+		return nil, err
+	}
+	allow, _, err := unstructured.NestedStringSlice(plugin.Object, "config", "allow")
+	return allow, err
+}
+
+func (kongRenderer) rendered(ingress *v1.Ingress) bool {
+	return slices.Contains(filterSliceFromString(strings.Split(ingress.Annotations[AnnotationKongPlugins], ",")), accessPluginName(ingress))
+}
+
+// deleteOwnedObject deletes obj if it exists and is controlled by the Ingress.
+// Objects owned by someone else are left untouched and refused with errNotOwned.
+func deleteOwnedObject(ctx context.Context, c client.Client, ingress *v1.Ingress, obj client.Object) error {
+	if err := c.Get(ctx, client.ObjectKeyFromObject(obj), obj); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+
+	if err := checkOwned(ingress, obj); err != nil {
+		return err
+	}
+
+	if err := c.Delete(ctx, obj); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	return nil
+}
+
+// setOrDeleteAnnotation sets the annotation on the Ingress, or removes it when value is empty.
+func setOrDeleteAnnotation(ingress *v1.Ingress, key string, value string) {
+	if value == "" {
+		delete(ingress.Annotations, key)
+		return
+	}
+	ingress.Annotations[key] = value
+}
+
+// toAnySlice converts a string slice to the representation used by unstructured objects.
+func toAnySlice(items []string) []any {
+	out := make([]any, 0, len(items))
+	for _, item := range items {
+		out = append(out, item)
+	}
+	return out
+}
@@ -0,0 +1,131 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	v1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// nginxRenderer writes the lists to the ingress-nginx source range annotations.
+// The annotation keys are configurable per IngressClass, and the keys written are recorded
+// on the Ingress so the renderer never overwrites annotations it does not own, except while
+// the Ingress is locked down: lists written by hand are then saved and restored when the lockdown is lifted.
+type nginxRenderer struct {
+	allowlistKey string
+	denylistKey  string
+	migrate      bool
+}
+
+// newNginxRenderer reads the annotation keys and migration mode from the IngressClass.
+func newNginxRenderer(ingressClass *v1.IngressClass) nginxRenderer {
+	renderer := nginxRenderer{
+		allowlistKey: AnnotationNginxWhitelist,
+		denylistKey:  AnnotationNginxDenylist,
+	}
+
+	if ingressClass == nil {
+		return renderer
+	}
+
+	annotations := ingressClass.GetAnnotations()
+	if key := strings.TrimSpace(annotations[AnnotationNginxAllowlistKey]); key != "" {
+		renderer.allowlistKey = key
+	}
+	if key := strings.TrimSpace(annotations[AnnotationNginxDenylistKey]); key != "" {
+		renderer.denylistKey = key
+	}
+	renderer.migrate = annotations[AnnotationNginxKeyMigration] == "true"
+
+	return renderer
+}
+
+func (nginxRenderer) name() string {
+	return RendererNginx
+}
+
+func (r nginxRenderer) render(_ context.Context, _ client.Client, _ *runtime.Scheme, ingress *v1.Ingress, allow []string, deny []string) error {
+	if err := r.renderList(ingress, r.allowlistKey, AnnotationManagedAllowlistKeys, AnnotationNginxWhitelist, AnnotationSavedAllowlist, allow); err != nil {
+		return err
+	}
+	return r.renderList(ingress, r.denylistKey, AnnotationManagedDenylistKeys, AnnotationNginxDenylist, AnnotationSavedDenylist, deny)
+}
+
+func (r nginxRenderer) allowlist(_ context.Context, _ client.Client, ingress *v1.Ingress) ([]string, error) {
+	owned := ownedKeys(ingress, AnnotationManagedAllowlistKeys, AnnotationNginxWhitelist)
+	if len(owned) == 0 {
+		return nil, nil
+	}
+	key := owned[0]
+	if slices.Contains(owned, r.allowlistKey) {
+		key = r.allowlistKey
+	}
+	return filterSliceFromString(strings.Split(ingress.Annotations[key], ",")), nil
+}
+
+func (nginxRenderer) rendered(ingress *v1.Ingress) bool {
+	return len(ownedKeys(ingress, AnnotationManagedAllowlistKeys, AnnotationNginxWhitelist)) > 0 ||
+		len(ownedKeys(ingress, AnnotationManagedDenylistKeys, AnnotationNginxDenylist)) > 0
+}
+
+// ownedKeys returns the keys the operator owns for a list, as recorded in managedKey.
+// Ingresses with access lists rendered before ownership was recorded are assumed to own the legacy key,
+// while the key is left to its author on other Ingresses, f.ex when they are locked down.
+func ownedKeys(ingress *v1.Ingress, managedKey string, legacyKey string) []string {
+	if _, recorded := ingress.Annotations[managedKey]; !recorded && ingressHasAnyAnnotation(ingress, ingressOwnAccessAnnotations...) {
+		if _, exists := ingress.Annotations[legacyKey]; exists {
+			return []string{legacyKey}
+		}
+	}
+	return filterSliceFromString(strings.Split(ingress.Annotations[managedKey], ","))
+}
+
+// renderList writes values to the configured key and to every other key the operator owns for the list.
+// In migration mode the other owned keys are removed instead. A value written by hand to the key is saved
+// in savedKey while the Ingress is locked down, and restored to the key once the lockdown is lifted.
+func (r nginxRenderer) renderList(ingress *v1.Ingress, key string, managedKey string, legacyKey string, savedKey string, values []string) error {
+	owned := ownedKeys(ingress, managedKey, legacyKey)
+	lockedDown := ingress.Annotations[AnnotationAppliedLockdown] != ""
+
+	if saved, found := ingress.Annotations[savedKey]; found && !lockedDown {
+		owned = slices.DeleteFunc(owned, func(ownedKey string) bool { return ownedKey == key })
+		ingress.Annotations[key] = saved
+		delete(ingress.Annotations, savedKey)
+	}
+
+	// Nothing to write, remove the keys owned by the operator
+	if len(values) == 0 {
+		for _, ownedKey := range owned {
+			delete(ingress.Annotations, ownedKey)
+		}
+		delete(ingress.Annotations, managedKey)
+		return nil
+	}
+
+	if !slices.Contains(owned, key) {
+		if value, exists := ingress.Annotations[key]; exists {
+			if !lockedDown {
+				return fmt.Errorf("refusing to overwrite annotation %s %w", key, errNotOwned)
+			}
+			ingress.Annotations[savedKey] = value
+		}
+		owned = append(owned, key)
+	}
+
+	var written []string
+	for _, ownedKey := range owned {
+		if ownedKey != key && r.migrate {
+			delete(ingress.Annotations, ownedKey)
+			continue
+		}
+		ingress.Annotations[ownedKey] = strings.Join(values, ",")
+		written = append(written, ownedKey)
+	}
+	ingress.Annotations[managedKey] = strings.Join(written, ",")
+
+	return nil
+}
@@ -0,0 +1,54 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	v1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// errInvalidSelector is returned for policy selector annotations that can't be parsed.
+var errInvalidSelector = errors.New("invalid policy selector")
+
+// selectNetworkPolicies returns the names of the NetworkPolicies in the default namespace matching the label selector.
+// An empty selector selects nothing.
+func selectNetworkPolicies(ctx context.Context, c client.Client, selectorValue string) ([]string, error) {
+	if strings.TrimSpace(selectorValue) == "" {
+		return nil, nil
+	}
+
+	selector, err := labels.Parse(selectorValue)
+	if err != nil {
+		return nil, fmt.Errorf("%w %q: %w", errInvalidSelector, selectorValue, err)
+	}
+
+	policyList := v1.NetworkPolicyList{}
+	if err := c.List(ctx, &policyList, client.InNamespace(DefaultNamespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, policy := range policyList.Items {
+		names = append(names, policy.Name)
+	}
+
+	return names, nil
+}
+
+// policySelectorMatches reports whether the label selector in the annotation value selects the NetworkPolicy.
+func policySelectorMatches(selectorValue string, policy *v1.NetworkPolicy) bool {
+	if strings.TrimSpace(selectorValue) == "" {
+		return false
+	}
+
+	selector, err := labels.Parse(selectorValue)
+	if err != nil {
+		return false
+	}
+
+	return selector.Matches(labels.Set(policy.Labels))
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var _ = Describe("NetworkPolicy selectors", func() {
+	ctx := context.Background()
+
+	newLabeledPolicy := func(name string, tier string, cidr string) *networkingv1.NetworkPolicy {
+		policy := newTestPolicy(name, nil, cidr)
+		policy.Labels = map[string]string{"access-tier": tier}
+		return policy
+	}
+
+	It("should follow NetworkPolicies entering and leaving the selection", func() {
+		ensureNamespace(ctx, DefaultNamespace)
+
+		oslo := newLabeledPolicy("selector-oslo", "internal", "10.50.0.0/24")
+		bergen := newLabeledPolicy("selector-bergen", "internal", "10.51.0.0/24")
+		Expect(k8sClient.Create(ctx, oslo)).To(Succeed())
+		Expect(k8sClient.Create(ctx, bergen)).To(Succeed())
+		defer func() {
+			Expect(k8sClient.Delete(ctx, oslo)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, bergen)).To(Succeed())
+		}()
+
+		ingress := newTestIngress("selector-app", map[string]string{
+			AnnotationWhiteListPolicySelector: "access-tier=internal",
+		})
+		Expect(k8sClient.Create(ctx, ingress)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, ingress)).To(Succeed()) }()
+
+		ingressKey := types.NamespacedName{Name: ingress.Name, Namespace: ingress.Namespace}
+		ingressReconciler := &IngressReconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+		updated := reconcileAndGet(ingressReconciler, ingressKey)
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxWhitelist, "10.50.0.0/24,10.51.0.0/24"))
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationSelectedWhitelist, "selector-bergen,selector-oslo"))
+
+		By("relabeling a NetworkPolicy out of the selection")
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: bergen.Name, Namespace: DefaultNamespace}, bergen)).To(Succeed())
+		bergen.Labels["access-tier"] = "partner"
+		Expect(k8sClient.Update(ctx, bergen)).To(Succeed())
+
+		policyReconciler := &NetworkPolicyReconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+		_, err := policyReconciler.Reconcile(ctx, reconcile.Request{
+			NamespacedName: types.NamespacedName{Name: bergen.Name, Namespace: DefaultNamespace},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(k8sClient.Get(ctx, ingressKey, updated)).To(Succeed())
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxWhitelist, "10.50.0.0/24"))
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationSelectedWhitelist, "selector-oslo"))
+	})
+	It("should record allow and deny selections separately and keep the current access for invalid selectors", func() {
+		ensureNamespace(ctx, DefaultNamespace)
+
+		office := newLabeledPolicy("selector-office", "internal", "10.52.0.0/24")
+		abuse := newLabeledPolicy("selector-abuse", "blocked", "10.52.0.128/25")
+		Expect(k8sClient.Create(ctx, office)).To(Succeed())
+		Expect(k8sClient.Create(ctx, abuse)).To(Succeed())
+		defer func() {
+			Expect(k8sClient.Delete(ctx, office)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, abuse)).To(Succeed())
+		}()
+
+		ingress := newTestIngress("selector-split-app", map[string]string{
+			AnnotationWhiteListPolicySelector: "access-tier=internal",
+			AnnotationDenyListPolicySelector:  "access-tier=blocked",
+		})
+		Expect(k8sClient.Create(ctx, ingress)).To(Succeed())
+		defer func() { Expect(k8sClient.Delete(ctx, ingress)).To(Succeed()) }()
+
+		recorder := record.NewFakeRecorder(10)
+		ingressKey := types.NamespacedName{Name: ingress.Name, Namespace: ingress.Namespace}
+		ingressReconciler := &IngressReconciler{Client: k8sClient, Scheme: k8sClient.Scheme(), Access: AccessConfig{Recorder: recorder}}
+		updated := reconcileAndGet(ingressReconciler, ingressKey)
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationSelectedWhitelist, "selector-office"))
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationSelectedDenylist, "selector-abuse"))
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxDenylist, "10.52.0.128/25"))
+
+		updated.Annotations[AnnotationDenyListPolicySelector] = "access-tier in (blocked"
+		Expect(k8sClient.Update(ctx, updated)).To(Succeed())
+		// An invalid selector keeps the current access, and is reported once without retrying
+		result, err := ingressReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: ingressKey})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(BeZero())
+		Eventually(recorder.Events).Should(Receive(ContainSubstring(EventReasonInvalidSelector)))
+
+		Expect(k8sClient.Get(ctx, ingressKey, updated)).To(Succeed())
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxWhitelist, "10.52.0.0/24"))
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationNginxDenylist, "10.52.0.128/25"))
+		Expect(updated.Annotations).To(HaveKeyWithValue(AnnotationInvalidSelector, ContainSubstring("access-tier in (blocked")))
+
+		_, err = ingressReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: ingressKey})
+		Expect(err).NotTo(HaveOccurred())
+		Consistently(recorder.Events).ShouldNot(Receive())
+
+		// Fixing the selector clears the record
+		Expect(k8sClient.Get(ctx, ingressKey, updated)).To(Succeed())
+		updated.Annotations[AnnotationDenyListPolicySelector] = "access-tier=blocked"
+		Expect(k8sClient.Update(ctx, updated)).To(Succeed())
+		updated = reconcileAndGet(ingressReconciler, ingressKey)
+		Expect(updated.Annotations).NotTo(HaveKey(AnnotationInvalidSelector))
+	})
+})
@@ -0,0 +1,98 @@
+package controller
+
+import (
+	"strings"
+)
+
+// ingressReferenceAnnotations are the Ingress annotations holding references to policies and sources,
+// including the default references of the namespace applied to the Ingress.
+var ingressReferenceAnnotations = []string{AnnotationWhiteListNetworkPolicy, AnnotationDenyListNetworkPolicy, AnnotationAppliedDefaults}
+
+// splitSourceReference splits a policy reference like configmap:ns/name/key into its kind and path.
+// Plain NetworkPolicy names have no kind.
+func splitSourceReference(reference string) (string, string) {
+	kind, path, found := strings.Cut(reference, ":")
+	if !found {
+		return "", reference
+	}
+	return strings.TrimSpace(kind), strings.TrimSpace(path)
+}
+
+// splitScheduleReference splits a reference or entry like feed:partners~maintenance into the reference
+// and the name of the AccessSchedule it is limited to, if any.
+func splitScheduleReference(reference string) (string, string) {
+	value, schedule, _ := strings.Cut(reference, ScheduleSeparator)
+	return strings.TrimSpace(value), strings.TrimSpace(schedule)
+}
+
+// policyReferences returns the references of a policy annotation, without their schedules.
+func policyReferences(annotation string) []string {
+	references := filterSliceFromString(strings.Split(annotation, ","))
+	for i, reference := range references {
+		references[i], _ = splitScheduleReference(reference)
+	}
+	return references
+}
+
+// parseObjectKeyReference parses a reference path of the form ns/name/key or name/key.
+// References without a namespace point to the default namespace.
+func parseObjectKeyReference(path string) (namespace string, name string, key string, ok bool) {
+	parts := strings.Split(path, "/")
+	switch len(parts) {
+	case 2:
+		namespace, name, key = DefaultNamespace, parts[0], parts[1]
+	case 3:
+		namespace, name, key = parts[0], parts[1], parts[2]
+	default:
+		return "", "", "", false
+	}
+	return namespace, name, key, namespace != "" && name != "" && key != ""
+}
+
+// parseObjectReference parses a reference path of the form ns/name or name.
+// References without a namespace point to the default namespace.
+func parseObjectReference(path string) (namespace string, name string, ok bool) {
+	parts := strings.Split(path, "/")
+	switch len(parts) {
+	case 1:
+		namespace, name = DefaultNamespace, parts[0]
+	case 2:
+		namespace, name = parts[0], parts[1]
+	default:
+		return "", "", false
+	}
+	return namespace, name, namespace != "" && name != ""
+}
+
+// parseSourceObject returns the namespace and name of the object referenced by the path,
+// which holds a key for ConfigMaps and Secrets, and no namespace for cluster scoped objects.
+func parseSourceObject(kind string, path string) (namespace string, name string, ok bool) {
+	switch kind {
+	case SourceConfigMap, SourceSecret:
+		namespace, name, _, ok = parseObjectKeyReference(path)
+		return namespace, name, ok
+	case SourceCalicoGlobalNetworkSet, SourceCiliumCIDRGroup:
+		// Cluster scoped objects have no namespace
+		return "", path, path != "" && !strings.Contains(path, "/")
+	default:
+		return parseObjectReference(path)
+	}
+}
+
+// ingressReferencesSource reports whether one of the reference annotations of the Ingress references
+// the object of the given source kind.
+func ingressReferencesSource(annotations map[string]string, kind string, namespace string, name string) bool {
+	for _, annotation := range ingressReferenceAnnotations {
+		for _, reference := range policyReferences(annotations[annotation]) {
+			referenceKind, path := splitSourceReference(reference)
+			if referenceKind != kind {
+				continue
+			}
+			referenceNamespace, referenceName, ok := parseSourceObject(kind, path)
+			if ok && referenceNamespace == namespace && referenceName == name {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,470 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	ingressnetworkpoliciesv1 "github.com/vitistack/ingressnetworkpolicy-operator/api/v1"
+)
+
+// AccessConfig holds the operator-wide configuration used when computing the access lists of an Ingress.
+type AccessConfig struct {
+	// DNS resolves dns: entries, they are skipped when unset.
+	DNS *DNSCache
+	// GeoIP resolves geo: entries, they are skipped when unset.
+	GeoIP *GeoIPDatabase
+	// Recorder emits events on Ingresses, f.ex when entries expire. No events are emitted when unset.
+	Recorder record.EventRecorder
+	// ACME is how the HTTP-01 challenges of cert-manager are handled.
+	ACME ACMEPolicy
+}
+
+// denyAllAllowlist is rendered for Ingresses requesting an allowlist that resolves to no CIDRs, since an empty
+// allowlist allows every address. It only allows the unspecified addresses, which never open connections.
+var denyAllAllowlist = []string{"0.0.0.0/32", "::/128"}
+
+// Reasons recorded when all access to an Ingress is denied.
+const (
+	denyAllEmptyAllowlist   = "allowlist resolves to no CIDRs"
+	denyAllRefusedAllowlist = "allowlist is refused and none was rendered before"
+	denyAllDenylistCovers   = "denylist covers the whole allowlist"
+)
+
+// updateIngressAccess computes the allow- and denylist for the given Ingress from its annotations
+// and renders them for the ingress controller serving the Ingress, before updating the Ingress.
+// It returns the time until the lists should be computed again, or zero when they only change with their inputs.
+func updateIngressAccess(ctx context.Context, c client.Client, scheme *runtime.Scheme, config AccessConfig, ingress *v1.Ingress) (time.Duration, error) {
+	log := logf.FromContext(ctx)
+
+	if ingress.Annotations == nil {
+		ingress.Annotations = map[string]string{}
+	}
+	originalAnnotations := maps.Clone(ingress.Annotations)
+
+	ingressClass, err := getIngressClass(ctx, c, ingress)
+	if err != nil {
+		log.Error(err, "unable to fetch IngressClass for Ingress", "Ingress.Name", ingress.Name)
+		return 0, err
+	}
+
+	// Handle the solver Ingresses of cert-manager according to the ACME policy instead of their annotations,
+	// the mandatory denylist and lockdowns apply to them like to every other Ingress
+	solver := ingressIsACMESolver(ingress)
+	access := ingress
+	var whitelist, denylist cidrList
+	var restricted bool
+	var refused error
+	managed := true
+	if solver {
+		whitelist.cidrs = config.ACME.solverAllowlist()
+		restricted = len(whitelist.cidrs) > 0
+		if len(whitelist.cidrs) == 0 {
+			log.Info("exempting cert-manager solver Ingress from access lists", "Ingress.Name", ingress.Name)
+		} else {
+			log.Info("allowing only the CA validation ranges on cert-manager solver Ingress", "Ingress.Name", ingress.Name, "CARanges", whitelist.cidrs)
+		}
+	} else {
+		lists, err := resolveIngressLists(ctx, c, config, ingress, ingressClass)
+		if errors.Is(err, errInvalidSelector) {
+			// Invalid selectors are fixed by changing the Ingress, keep the current access without retrying
+			return 0, recordInvalidSelector(ctx, c, config, ingress, originalAnnotations, err)
+		}
+		if err != nil {
+			return 0, err
+		}
+		if lists != nil {
+			access, whitelist, denylist, restricted, refused = lists.access, lists.whitelist, lists.denylist, lists.restricted, lists.refused
+		}
+		// Ingresses that opted out of the namespace defaults and have never been managed are only locked down
+		managed = lists != nil && ingressManaged(ingress)
+	}
+	delete(ingress.Annotations, AnnotationInvalidSelector)
+
+	// Replace the allowlist with the break-glass policy while locked down, lifting the lockdown restores the computed allowlist
+	lockdown, requested, err := lockdownForIngress(ctx, c, access)
+	if err != nil {
+		log.Error(err, "unable to get lockdown for Ingress", "Ingress.Name", ingress.Name)
+		if errors.Is(err, errLockdownNotSelected) && config.Recorder != nil {
+			config.Recorder.Event(ingress, corev1.EventTypeWarning, EventReasonLockdownFailed, err.Error())
+		}
+		return 0, err
+	}
+
+	// Merge the mandatory denylist, which the annotations of the Ingress can't remove. Ingresses not managed by the operator
+	// only get lists while locked down, so lifting the lockdown removes them again.
+	var mandatory cidrList
+	var emergencyBlocks []string
+	if managed || lockdown != nil {
+		if mandatory, emergencyBlocks, err = createMandatoryDenylist(ctx, c, config, ingress); err != nil {
+			log.Error(err, "unable to resolve mandatory denylist for Ingress", "Ingress.Name", ingress.Name)
+			return 0, err
+		}
+	}
+
+	cidrWhitelist, cidrDenylist := whitelist.cidrs, sortSlice(slices.Concat(denylist.cidrs, mandatory.cidrs))
+
+	var breakGlass cidrList
+	if lockdown != nil {
+		if breakGlass, err = createBreakGlassList(ctx, c, config, ingress, lockdown); err != nil {
+			log.Error(err, "unable to lock down Ingress", "Ingress.Name", ingress.Name)
+			if config.Recorder != nil {
+				config.Recorder.Event(ingress, corev1.EventTypeWarning, EventReasonLockdownFailed, err.Error())
+			}
+			return 0, err
+		}
+		cidrWhitelist = breakGlass.cidrs
+		if requested {
+			// The break-glass policy replaces the refused allowlist
+			refused = nil
+		}
+	}
+
+	var requeueAfter time.Duration
+	if refreshAt := earliest(earliest(whitelist.refreshAt, denylist.refreshAt), earliest(mandatory.refreshAt, breakGlass.refreshAt)); !refreshAt.IsZero() {
+		requeueAfter = max(time.Until(refreshAt), time.Second)
+	}
+
+	// Refuse allowlists violating the guardrails of the namespace, including break-glass policies requested by the Ingress.
+	// Namespace and cluster lockdowns and the ACME policy are imposed by cluster administrators and are not checked.
+	if (lockdown == nil || requested) && !solver && refused == nil {
+		if err := checkGuardrails(ctx, c, ingress.Namespace, cidrWhitelist); err != nil {
+			if !errors.Is(err, errGuardrailViolation) {
+				log.Error(err, "unable to check guardrails for Ingress", "Ingress.Name", ingress.Name)
+				return 0, err
+			}
+			log.Error(err, "refusing allowlist for Ingress", "Ingress.Name", ingress.Name)
+			if config.Recorder != nil {
+				config.Recorder.Event(ingress, corev1.EventTypeWarning, EventReasonGuardrailViolation, err.Error())
+			}
+			refused = err
+			lockdown = nil
+		}
+	}
+
+	// Keep the rendered allowlist when the computed one is refused, the denylist is still rendered as computed,
+	// so the mandatory denylist and emergency blocks apply regardless
+	renderer := selectAccessRenderer(ingressClass)
+	if lockdown == nil && refused != nil {
+		if cidrWhitelist, err = renderer.allowlist(ctx, c, ingress); err != nil {
+			log.Error(err, "unable to get rendered allowlist for Ingress", "Ingress.Name", ingress.Name, "Renderer", renderer.name())
+			return 0, err
+		}
+	}
+
+	// Record expired entries, refused NetworkPolicies, unresolved sources and emergency blocks, and announce the ones added since the last update
+	recordNewEntries(config, ingress, AnnotationExpiredEntries, sortSlice(slices.Concat(whitelist.expired, denylist.expired)),
+		corev1.EventTypeNormal, EventReasonAccessExpired, "Access expired for %s")
+	recordNewEntries(config, ingress, AnnotationUnauthorizedPolicies, sortSlice(slices.Concat(whitelist.unauthorized, denylist.unauthorized)),
+		corev1.EventTypeWarning, EventReasonUnauthorizedPolicy, "The namespace is not authorized to reference %s")
+	recordNewEntries(config, ingress, AnnotationUnresolvedSources, sortSlice(slices.Concat(whitelist.unresolved, denylist.unresolved)),
+		corev1.EventTypeWarning, EventReasonUnresolvedSource, "Unable to resolve %s, they contribute no CIDRs")
+	recordNewEntries(config, ingress, AnnotationEmergencyBlocks, emergencyBlocks,
+		corev1.EventTypeWarning, EventReasonEmergencyBlock, "Emergency block applied for %s")
+	recordLockdown(config, ingress, lockdown)
+
+	// Report how the lists of the Ingress overlap, the mandatory denylist applies to every Ingress alike
+	recordAccessFindings(config, ingress, analyzeAccess(cidrWhitelist, denylist.cidrs))
+
+	// Allow the CA validation ranges while cert-manager solves a challenge through a restricted Ingress
+	if (len(cidrWhitelist) > 0 || restricted) && ingressHasACMEChallenge(ingress) {
+		if len(config.ACME.CARanges) == 0 {
+			log.Info("cert-manager challenge path on restricted Ingress, but no CA validation ranges are configured", "Ingress.Name", ingress.Name)
+		} else {
+			log.Info("allowing the CA validation ranges for cert-manager challenge on Ingress", "Ingress.Name", ingress.Name, "CARanges", config.ACME.CARanges)
+			cidrWhitelist = sortSlice(slices.Concat(cidrWhitelist, config.ACME.CARanges))
+		}
+	}
+
+	// Subtract the denylist for ingress controllers without deny support and renderers unable to combine it with an allowlist
+	var coveredByDenylist bool
+	if len(cidrWhitelist) > 0 && len(cidrDenylist) > 0 {
+		if subtractDenylist(ingressClass) || !rendersDenyWithAllow(renderer) {
+			cidrWhitelist = subtractCIDRs(cidrWhitelist, cidrDenylist)
+			cidrDenylist = nil
+		}
+		// An empty allowlist would allow every address, all access is denied below instead
+		coveredByDenylist = len(cidrWhitelist) == 0
+	}
+
+	// Deny all access instead of opening Ingresses whose requested allowlist resolves to no CIDRs, f.ex once all
+	// of its entries expired or its sources can't be resolved, is covered by the denylist, or is refused before
+	// any allowlist was rendered
+	var denyAll []string
+	if (restricted || coveredByDenylist) && len(cidrWhitelist) == 0 {
+		reason := denyAllEmptyAllowlist
+		switch {
+		case coveredByDenylist:
+			reason = denyAllDenylistCovers
+		case refused != nil && lockdown == nil:
+			reason = denyAllRefusedAllowlist
+		}
+		log.Info("denying all access to Ingress", "Ingress.Name", ingress.Name, "Reason", reason)
+		denyAll = []string{reason}
+		cidrWhitelist = denyAllAllowlist
+	}
+	recordNewEntries(config, ingress, AnnotationDenyAll, denyAll, corev1.EventTypeWarning, EventReasonDenyAll, "Denying all access, the %s")
+
+	// Render the lists for the ingress controller serving the Ingress
+	if err := renderIngressAccess(ctx, c, scheme, renderer, ingress, originalAnnotations, cidrWhitelist, cidrDenylist); err != nil {
+		if errors.Is(err, errNotOwned) {
+			// Objects and annotations written by hand are fixed by removing them, retrying can't succeed
+			if config.Recorder != nil {
+				config.Recorder.Eventf(ingress, corev1.EventTypeWarning, EventReasonNotOwned, "Keeping the current access lists: %s", err)
+			}
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	return requeueAfter, nil
+}
+
+// ingressLists are the allow- and denylist requested by the annotations of an Ingress.
+type ingressLists struct {
+	// access is the Ingress the annotations are read from, the primary Ingress for canaries.
+	access *v1.Ingress
+
+	whitelist, denylist cidrList
+	// restricted reports whether the Ingress requests an allowlist, so it must never be opened by it resolving to no CIDRs.
+	restricted bool
+	// refused is why the allowlist was refused, f.ex an invalid whitelist combination.
+	refused error
+}
+
+// resolveIngressLists resolves the allow- and denylist requested by the annotations of the Ingress,
+// by the default references of its namespace and by its primary Ingress for canaries, and records
+// how they were resolved on the Ingress. It returns nil when the Ingress opted out of the namespace
+// defaults and has never been managed.
+func resolveIngressLists(ctx context.Context, c client.Client, config AccessConfig, ingress *v1.Ingress, ingressClass *v1.IngressClass) (*ingressLists, error) {
+	log := logf.FromContext(ctx)
+
+	// Canaries inherit the access lists of their primary Ingress, resolved from the annotations of the primary
+	access := ingress
+	primary, err := canaryPrimary(ctx, c, ingress)
+	if err != nil {
+		log.Error(err, "unable to find the primary Ingress of canary", "Ingress.Name", ingress.Name)
+		return nil, err
+	}
+	var primaryName string
+	if primary != nil {
+		var differing []string
+		access, differing = canaryAccessSource(ingress, primary)
+		primaryName = primary.Name
+		if len(differing) > 0 {
+			log.Info("canary declares access annotations differing from its primary Ingress", "Ingress.Name", ingress.Name, "Primary.Name", primary.Name, "Annotations", differing)
+			if config.Recorder != nil {
+				config.Recorder.Eventf(ingress, corev1.EventTypeWarning, EventReasonCanaryAccessMismatch,
+					"The canary inherits the access lists of %s, its differing annotations %s are ignored", primary.Name, strings.Join(differing, ","))
+			}
+		}
+	}
+	setOrDeleteAnnotation(ingress, AnnotationCanaryOf, primaryName)
+
+	// Get Annotations from Ingress
+	annotationWhiteListNetworkPolicy := access.GetAnnotations()[AnnotationWhiteListNetworkPolicy]
+	annotationDenyListNetworkPolicy := access.GetAnnotations()[AnnotationDenyListNetworkPolicy]
+	annotationWhitelist := access.GetAnnotations()[AnnotationWhitelist]
+	annotationDenylist := access.GetAnnotations()[AnnotationDenylist]
+
+	// Create slices from annotations
+	sliceWhitelistNetworkPolicy := filterSliceFromString(strings.Split(annotationWhiteListNetworkPolicy, ","))
+	sliceDenyListNetworkPolicy := filterSliceFromString(strings.Split(annotationDenyListNetworkPolicy, ","))
+	sliceWhitelist := filterSliceFromString(strings.Split(annotationWhitelist, ","))
+	sliceDenylist := filterSliceFromString(strings.Split(annotationDenylist, ","))
+
+	// Apply the default references of the namespace, and record them
+	defaults, err := namespaceDefaultsForIngress(ctx, c, access)
+	if err != nil {
+		log.Error(err, "unable to get namespace defaults for Ingress", "Ingress.Name", ingress.Name)
+		return nil, err
+	}
+	if defaults.optOutRefused {
+		log.Info("guardrail forbids opting out of the namespace defaults for Ingress", "Ingress.Name", ingress.Name)
+		if config.Recorder != nil {
+			config.Recorder.Event(ingress, corev1.EventTypeWarning, EventReasonOptOutRefused, "A guardrail of the namespace forbids opting out of its default references")
+		}
+	}
+	if defaults.optedOut && ingress.Annotations[AnnotationAppliedDefaults] == "" && !ingressHasAnyAnnotation(access, ingressOwnAccessAnnotations...) {
+		return nil, nil
+	}
+	declaredWhitelistPolicies := sliceWhitelistNetworkPolicy
+	sliceWhitelistNetworkPolicy = append(defaults.whitelist, sliceWhitelistNetworkPolicy...)
+	sliceDenyListNetworkPolicy = append(defaults.denylist, sliceDenyListNetworkPolicy...)
+	setOrDeleteAnnotation(ingress, AnnotationAppliedDefaults, strings.Join(defaults.references(), ","))
+
+	// Resolve NetworkPolicies selected by label, and record the selections.
+	// The current access is kept while a selector is invalid or the selection fails, only the latter is retried.
+	selectedWhitelistPolicies, err := selectNetworkPolicies(ctx, c, access.Annotations[AnnotationWhiteListPolicySelector])
+	var selectedDenyListPolicies []string
+	if err == nil {
+		selectedDenyListPolicies, err = selectNetworkPolicies(ctx, c, access.Annotations[AnnotationDenyListPolicySelector])
+	}
+	if err != nil {
+		log.Error(err, "unable to select NetworkPolicies for Ingress", "Ingress.Name", ingress.Name)
+		return nil, err
+	}
+	sliceWhitelistNetworkPolicy = append(sliceWhitelistNetworkPolicy, selectedWhitelistPolicies...)
+	sliceDenyListNetworkPolicy = append(sliceDenyListNetworkPolicy, selectedDenyListPolicies...)
+	setOrDeleteAnnotation(ingress, AnnotationSelectedWhitelist, strings.Join(sortSlice(selectedWhitelistPolicies), ","))
+	setOrDeleteAnnotation(ingress, AnnotationSelectedDenylist, strings.Join(sortSlice(selectedDenyListPolicies), ","))
+
+	// Only use policy rules matching the requested ports, if any
+	ports := policyPortsForIngress(access, ingressClass)
+
+	// Inherit CIDRs from NetworkPolicies selecting the backend pods, if requested.
+	// Canaries inherit from the backends of their primary Ingress, like the rest of its access lists.
+	if ingressInheritsBackendPolicies(access) {
+		backends := ingress
+		if primary != nil {
+			backends = primary
+		}
+		inheritedCIDRs, inheritedPolicies, err := inheritBackendCIDRs(ctx, c, backends)
+		if err != nil {
+			log.Error(err, "unable to inherit CIDRs from backend NetworkPolicies", "Ingress.Name", ingress.Name)
+			return nil, err
+		}
+		sliceWhitelist = append(sliceWhitelist, inheritedCIDRs...)
+		setOrDeleteAnnotation(ingress, AnnotationInheritedPolicies, strings.Join(inheritedPolicies, ","))
+	} else {
+		delete(ingress.Annotations, AnnotationInheritedPolicies)
+	}
+
+	// Create CIDR Lists
+	lists := &ingressLists{
+		access:     access,
+		restricted: len(sliceWhitelistNetworkPolicy) > 0 || len(sliceWhitelist) > 0 || access.Annotations[AnnotationWhiteListPolicySelector] != "",
+	}
+
+	// Combine the whitelist references as requested, refusing malformed combinations and unresolved operands keeps the rendered allowlist
+	combination, err := whitelistCombination(access, sliceWhitelistNetworkPolicy, sliceWhitelist, declaredWhitelistPolicies)
+	switch {
+	case err != nil:
+	case combination != nil:
+		lists.whitelist, err = createCombinedCidrList(ctx, c, config, ingress, combination, sliceWhitelistNetworkPolicy, sliceWhitelist, ports)
+	case len(sliceWhitelistNetworkPolicy) > 0 || len(sliceWhitelist) > 0:
+		lists.whitelist = createCidrList(ctx, c, config, ingress, ingress.Namespace, sliceWhitelistNetworkPolicy, sliceWhitelist, ports, false)
+	}
+	if err != nil {
+		log.Error(err, "refusing whitelist combination for Ingress", "Ingress.Name", ingress.Name)
+		if config.Recorder != nil {
+			config.Recorder.Event(ingress, corev1.EventTypeWarning, EventReasonInvalidCombination, err.Error())
+		}
+		lists.refused = err
+	}
+
+	if len(sliceDenyListNetworkPolicy) > 0 || len(sliceDenylist) > 0 {
+		lists.denylist = createCidrList(ctx, c, config, ingress, ingress.Namespace, sliceDenyListNetworkPolicy, sliceDenylist, ports, true)
+	}
+
+	return lists, nil
+}
+
+// renderIngressAccess renders the lists for the ingress controller serving the Ingress,
+// and updates the Ingress unless its annotations are unchanged.
+func renderIngressAccess(ctx context.Context, c client.Client, scheme *runtime.Scheme, renderer accessRenderer, ingress *v1.Ingress, originalAnnotations map[string]string, allow []string, deny []string) error {
+	log := logf.FromContext(ctx)
+
+	if err := removeStaleRenderings(ctx, c, scheme, renderer, ingress); err != nil {
+		log.Error(err, "unable to remove stale access lists for Ingress", "Ingress.Name", ingress.Name, "Renderer", renderer.name())
+		return err
+	}
+	if err := renderer.render(ctx, c, scheme, ingress, allow, deny); err != nil {
+		log.Error(err, "unable to render access lists for Ingress", "Ingress.Name", ingress.Name, "Renderer", renderer.name())
+		return err
+	}
+
+	// Validate annotations before updating
+	if err := validateAnnotations(ingress.Annotations); err != nil {
+		log.Error(err, "invalid annotations for Ingress", "Ingress.Name", ingress.Name)
+		return err
+	}
+
+	// Skip the update when nothing changed, sources like feeds trigger frequent reconciles
+	if maps.Equal(originalAnnotations, ingress.Annotations) {
+		return nil
+	}
+
+	// Update Ingress
+	if err := c.Update(ctx, ingress); err != nil {
+		log.Error(err, "unable to remove Ingress annotation", "Ingress.Name", ingress.Name)
+		return err
+	}
+
+	// Log successful update
+	log.Info("Updated Ingress annotation", "Ingress.Name", ingress.Name, "Renderer", renderer.name())
+
+	return nil
+}
+
+// recordInvalidSelector records the invalid policy selector on the Ingress, leaving its access as it is,
+// and emits an event unless the same error was recorded before.
+func recordInvalidSelector(ctx context.Context, c client.Client, config AccessConfig, ingress *v1.Ingress, originalAnnotations map[string]string, selectorErr error) error {
+	if originalAnnotations[AnnotationInvalidSelector] == selectorErr.Error() {
+		return nil
+	}
+
+	if config.Recorder != nil {
+		config.Recorder.Eventf(ingress, corev1.EventTypeWarning, EventReasonInvalidSelector, "Keeping the current access lists: %s", selectorErr)
+	}
+
+	ingress.Annotations = maps.Clone(originalAnnotations)
+	ingress.Annotations[AnnotationInvalidSelector] = selectorErr.Error()
+	if err := c.Update(ctx, ingress); err != nil {
+		logf.FromContext(ctx).Error(err, "unable to record invalid selector on Ingress", "Ingress.Name", ingress.Name)
+		return err
+	}
+	return nil
+}
+
+// recordLockdown records the LockdownPolicy applied to the Ingress, and emits an event when a lockdown is applied or lifted.
+func recordLockdown(config AccessConfig, ingress *v1.Ingress, lockdown *ingressnetworkpoliciesv1.LockdownPolicy) {
+	previous := ingress.Annotations[AnnotationAppliedLockdown]
+
+	var current string
+	if lockdown != nil {
+		current = lockdown.Name
+	}
+	setOrDeleteAnnotation(ingress, AnnotationAppliedLockdown, current)
+
+	if current == previous || config.Recorder == nil {
+		return
+	}
+	switch {
+	case current == "":
+		config.Recorder.Eventf(ingress, corev1.EventTypeNormal, EventReasonLockdownLifted, "Lockdown %s lifted, the computed allowlist is restored", previous)
+	case lockdown.Spec.Reason != "":
+		config.Recorder.Eventf(ingress, corev1.EventTypeWarning, EventReasonLockdownApplied, "Locked down to the break-glass policy %s: %s", current, lockdown.Spec.Reason)
+	default:
+		config.Recorder.Eventf(ingress, corev1.EventTypeWarning, EventReasonLockdownApplied, "Locked down to the break-glass policy %s", current)
+	}
+}
+
+// recordNewEntries records the entries in the bookkeeping annotation of the Ingress,
+// and emits an event for the entries that were not recorded at the last update.
+func recordNewEntries(config AccessConfig, ingress *v1.Ingress, annotation string, entries []string, eventType string, reason string, messageFmt string) {
+	previous := filterSliceFromString(strings.Split(ingress.Annotations[annotation], ","))
+
+	var added []string
+	for _, entry := range entries {
+		if !slices.Contains(previous, entry) {
+			added = append(added, entry)
+		}
+	}
+
+	if len(added) > 0 && config.Recorder != nil {
+		config.Recorder.Eventf(ingress, eventType, reason, messageFmt, strings.Join(added, ","))
+	}
+
+	setOrDeleteAnnotation(ingress, annotation, strings.Join(entries, ","))
+}
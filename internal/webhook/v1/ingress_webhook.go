@@ -0,0 +1,93 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/vitistack/ingressnetworkpolicy-operator/internal/controller"
+)
+
+// log is for logging in this package.
+var ingresslog = logf.Log.WithName("ingress-resource")
+
+// SetupIngressWebhookWithManager registers the webhook for Ingress in the manager.
+func SetupIngressWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(&networkingv1.Ingress{}).
+		WithValidator(&IngressCustomValidator{Client: mgr.GetClient()}).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/validate-networking-k8s-io-v1-ingress,mutating=false,failurePolicy=fail,sideEffects=None,groups=networking.k8s.io,resources=ingresses,verbs=create;update,versions=v1,name=vingress-v1.kb.io,admissionReviewVersions=v1
+
+// IngressCustomValidator rejects Ingresses whose whitelist entries violate the AccessGuardrails of their namespace,
+// and Ingresses with a malformed whitelist combination.
+type IngressCustomValidator struct {
+	Client client.Reader
+}
+
+var _ webhook.CustomValidator = &IngressCustomValidator{}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type Ingress.
+func (v *IngressCustomValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	ingress, ok := obj.(*networkingv1.Ingress)
+	if !ok {
+		return nil, fmt.Errorf("expected an Ingress object but got %T", obj)
+	}
+	ingresslog.Info("Validation for Ingress upon creation", "name", ingress.GetName())
+
+	return nil, errors.Join(controller.ValidateIngressCombination(ingress), controller.ValidateIngressGuardrails(ctx, v.Client, ingress))
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type Ingress.
+// Only changed whitelist entries and combinations are validated, so updates by other controllers are not blocked by guardrails added later.
+func (v *IngressCustomValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	oldIngress, ok := oldObj.(*networkingv1.Ingress)
+	if !ok {
+		return nil, fmt.Errorf("expected an Ingress object for the oldObj but got %T", oldObj)
+	}
+	ingress, ok := newObj.(*networkingv1.Ingress)
+	if !ok {
+		return nil, fmt.Errorf("expected an Ingress object for the newObj but got %T", newObj)
+	}
+	ingresslog.Info("Validation for Ingress upon update", "name", ingress.GetName())
+
+	var errs []error
+	if oldIngress.GetAnnotations()[controller.AnnotationWhitelistCombination] != ingress.GetAnnotations()[controller.AnnotationWhitelistCombination] {
+		errs = append(errs, controller.ValidateIngressCombination(ingress))
+	}
+	if oldIngress.GetAnnotations()[controller.AnnotationWhitelist] != ingress.GetAnnotations()[controller.AnnotationWhitelist] {
+		errs = append(errs, controller.ValidateIngressGuardrails(ctx, v.Client, ingress))
+	}
+
+	return nil, errors.Join(errs...)
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type Ingress.
+func (v *IngressCustomValidator) ValidateDelete(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
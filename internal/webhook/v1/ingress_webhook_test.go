@@ -0,0 +1,77 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	ingressnetworkpoliciesv1 "github.com/vitistack/ingressnetworkpolicy-operator/api/v1"
+	"github.com/vitistack/ingressnetworkpolicy-operator/internal/controller"
+)
+
+var _ = Describe("Ingress Webhook", func() {
+	var validator *IngressCustomValidator
+
+	newIngress := func(whitelist string) *networkingv1.Ingress {
+		return &networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "webhook-app",
+				Namespace:   "default",
+				Annotations: map[string]string{controller.AnnotationWhitelist: whitelist},
+			},
+		}
+	}
+
+	BeforeEach(func() {
+		validator = &IngressCustomValidator{Client: k8sClient}
+
+		guardrail := &ingressnetworkpoliciesv1.AccessGuardrail{
+			ObjectMeta: metav1.ObjectMeta{Name: "webhook-min-prefix"},
+			Spec: ingressnetworkpoliciesv1.AccessGuardrailSpec{
+				MinPrefixLengthIPv4: ptr.To[int32](8),
+				MinPrefixLengthIPv6: ptr.To[int32](32),
+			},
+		}
+		Expect(k8sClient.Create(ctx, guardrail)).To(Succeed())
+		DeferCleanup(func() { Expect(k8sClient.Delete(ctx, guardrail)).To(Succeed()) })
+	})
+
+	It("should reject creating Ingresses whose whitelist violates a guardrail", func() {
+		_, err := validator.ValidateCreate(ctx, newIngress("10.0.0.0/24,0.0.0.0/0"))
+		Expect(err).To(MatchError(ContainSubstring("0.0.0.0/0 is broader than /8")))
+
+		_, err = validator.ValidateCreate(ctx, newIngress("10.0.0.0/24"))
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should only validate the whitelist on updates changing it", func() {
+		// Guardrails added after the Ingress don't block updates by other controllers
+		oldIngress := newIngress("0.0.0.0/0")
+		ingress := oldIngress.DeepCopy()
+		ingress.Labels = map[string]string{"app": "webhook-app"}
+		_, err := validator.ValidateUpdate(ctx, oldIngress, ingress)
+		Expect(err).NotTo(HaveOccurred())
+
+		ingress.Annotations[controller.AnnotationWhitelist] = "0.0.0.0/0,::/0"
+		_, err = validator.ValidateUpdate(ctx, oldIngress, ingress)
+		Expect(err).To(MatchError(ContainSubstring("::/0 is broader than /32")))
+	})
+})